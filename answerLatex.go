@@ -10,13 +10,38 @@ func (r *ReadingCompSt) AnswerLatex(isKey, showAll bool, student uint, qNum *Que
 	questions := r.Questions.get(student)
 	quest, _ := questions.Get(0)
 	if quest.Choices.Size() == 0 {
-		return append(outStr, answerLines(questions, isKey, r.GetHead().NumLines)...)
+		return append(outStr, answerLines(questions, isKey, r.QuestionPoints, r.NumLines)...)
 	}
 	start := qNum.CurrentNumber() + 1
 	end := qNum.CurrentNumber() + uint32(r.NumQuest)
 	qNum.AddNumber(uint32(r.NumQuest))
 	outStr = append(outStr, fmt.Sprintf(`\answerBox{%d}{%d}`, start, end))
-	if isKey {
+	switch {
+	case isKey && questions.hasSelectAll():
+		outStr = append(outStr, selectAllKey(questions, start)...)
+	case isKey:
+		outStr = append(outStr,
+			fmt.Sprintf(`{%s%s}`, strings.Repeat("x", int(start)-1),
+				questionAnswerString(questions)))
+	}
+	return outStr
+}
+
+func (s *StimulusGroupsSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(s.SectionTitle, s.Points, "")
+	questions := s.Questions.get(student)
+	quest, _ := questions.Get(0)
+	if quest == nil || quest.Choices.Size() == 0 {
+		return append(outStr, answerLines(questions, isKey, s.QuestionPoints, s.NumLines)...)
+	}
+	start := qNum.CurrentNumber() + 1
+	end := qNum.CurrentNumber() + uint32(questions.Size())
+	qNum.AddNumber(uint32(questions.Size()))
+	outStr = append(outStr, fmt.Sprintf(`\answerBox{%d}{%d}`, start, end))
+	switch {
+	case isKey && questions.hasSelectAll():
+		outStr = append(outStr, selectAllKey(questions, start)...)
+	case isKey:
 		outStr = append(outStr,
 			fmt.Sprintf(`{%s%s}`, strings.Repeat("x", int(start)-1),
 				questionAnswerString(questions)))
@@ -32,14 +57,123 @@ func (m *MultipleChoiceSt) AnswerLatex(isKey, showAll bool, student uint, qNum *
 		strings.Join(testSectionBegin(m.SectionTitle, m.Points, ""), "\n"),
 		fmt.Sprintf(`\answerBox{%d}{%d}`, start, end),
 	}
-	if isKey {
+	questions := m.Questions.get(student)
+	switch {
+	case isKey && questions.hasSelectAll():
+		outStr = append(outStr, selectAllKey(questions, start)...)
+	case isKey:
 		outStr = append(outStr,
 			fmt.Sprintf(`{%s%s}`, strings.Repeat("x", int(start)-1),
-				questionAnswerString(m.Questions.get(student))))
+				questionAnswerString(questions)))
+	}
+	return outStr
+}
+
+func (t *TrueFalseSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(t.SectionTitle, t.Points, "")
+	questions := t.Questions.get(student)
+	start := qNum.CurrentNumber()
+	qNum.AddNumber(uint32(questions.Size()))
+	outStr = append(outStr, trueFalseGrid(questions, start, isKey)...)
+
+	if !isKey || !t.CorrectFalse {
+		return outStr
+	}
+	corrections := make([]string, 0)
+	questions.Each(
+		func(qi int, q *QuestionsSt) {
+			if !q.True && q.Correction.string != "" {
+				corrections = append(corrections,
+					fmt.Sprintf(`\item[%d.] %s`, int(start)+qi+1, q.Correction.string))
+			}
+		},
+	)
+	if len(corrections) == 0 {
+		return outStr
 	}
+	return append(outStr, `\begin{enumerate}`, strings.Join(corrections, "\n"), `\end{enumerate}`)
+}
+
+func (n *NumericSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(n.SectionTitle, n.Points, "")
+	questions := n.Questions.get(student)
+	start := qNum.CurrentNumber()
+	qNum.AddNumber(uint32(questions.Size()))
+	if !isKey {
+		return append(outStr, answerLines(questions, isKey, n.QuestionPoints, n.NumLines)...)
+	}
+	return append(outStr, []string{
+		`\begin{enumerate}`,
+		fmt.Sprintf(`\setcounter{enumi}{%d}`, start),
+		`\large`,
+		strings.Join(stringsUsing(questions.Values(), func(q *QuestionsSt) string {
+			answer := strings.Join(q.Answers.Values(), "\n")
+			if n.QuestionPoints || len(q.PartPoints) != 0 {
+				return fmt.Sprintf(`\item %s %s`, pointsText(q.Points), answer)
+			}
+			return fmt.Sprintf(`\item %s`, answer)
+		}), "\n"),
+		`\normalsize`,
+		`\end{enumerate}`,
+	}...)
+}
+
+func (o *OrderingSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(o.SectionTitle, o.Points, "")
+	questions := o.Questions.get(student)
+	start := qNum.CurrentNumber()
+	qNum.AddNumber(uint32(questions.Size()))
+	return append(outStr, []string{
+		`\begin{enumerate}`,
+		fmt.Sprintf(`\setcounter{enumi}{%d}`, start),
+		`\large`,
+		strings.Join(stringsUsing(questions.Values(), func(q *QuestionsSt) string {
+			if isKey {
+				answer, _ := q.Answers.Get(0)
+				return fmt.Sprintf(`\item %s`, answer)
+			}
+			return fmt.Sprintf(`\item %s`, q.orderBlanks(`\rule{1cm}{0.4pt}`))
+		}), "\n"),
+		`\normalsize`,
+		`\end{enumerate}`,
+	}...)
+}
+
+func (c *ClozeSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(c.SectionTitle, c.Points, "")
+	return append(outStr, blankAnswers(c.Questions.get(student), isKey, c.QuestionPoints, qNum)...)
+}
+
+func (e *EssaySt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(e.SectionTitle, e.Points, "")
+	e.Questions.get(student).Each(
+		func(_ int, q *QuestionsSt) {
+			number := fmt.Sprintf(`\noindent\textbf{%d.}`, qNum.NextNumber())
+			if e.QuestionPoints {
+				number += " " + pointsText(q.Points)
+			}
+			outStr = append(outStr, number)
+			switch {
+			case !isKey:
+				outStr = append(outStr, fmt.Sprintf(`\fillwithlines{%s}`, ternary(e.NumLines == "", "8cm", e.NumLines)))
+			case q.Answers.Size() != 0:
+				outStr = append(outStr, strings.Join(q.Answers.Values(), "\n"), `\par`)
+			default:
+				outStr = append(outStr, `\par`)
+			}
+			if q.Rubric != nil {
+				outStr = append(outStr, q.Rubric.latex()...)
+			}
+		},
+	)
 	return outStr
 }
 
+func (d *DiagramSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(d.SectionTitle, d.Points, "")
+	return append(outStr, blankAnswers(d.Questions.get(student), isKey, d.QuestionPoints, qNum)...)
+}
+
 func (w *WordProblemSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
 	outStr := testSectionBegin(w.SectionTitle, w.Points, "")
 
@@ -53,7 +187,7 @@ func (w *WordProblemSt) AnswerLatex(isKey, showAll bool, student uint, qNum *Que
 		}...)
 	}
 
-	return append(outStr, answerTable(w.Questions.get(student), isKey)...)
+	return append(outStr, answerTable(w.Questions.get(student), isKey, w.QuestionPoints)...)
 }
 
 func (q *QuizSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
@@ -69,7 +203,7 @@ func (q *QuizSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt
 		}...)
 	}
 
-	return append(outStr, answerTable(q.Questions.get(student), isKey)...)
+	return append(outStr, answerTable(q.Questions.get(student), isKey, q.QuestionPoints)...)
 }
 
 func (w *WordMatchSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
@@ -104,7 +238,7 @@ func (p *PassageCompletionSt) AnswerLatex(isKey, showAll bool, student uint, qNu
 func (c *CompQuestionsSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
 	outStr := testSectionBegin(c.SectionTitle, c.Points, "")
 
-	return append(outStr, answerLines(c.Questions.get(student), isKey, c.GetHead().NumLines)...)
+	return append(outStr, answerLines(c.Questions.get(student), isKey, c.QuestionPoints, c.NumLines)...)
 }
 
 func (c *CustomSt) AnswerLatex(isKey, showAll bool, student uint, qNum *QuestNumSt) []string {
@@ -0,0 +1,352 @@
+package testparts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+)
+
+// Questions imported from other formats' question banks are read as bank
+// items of a few kinds, each fitting some section types. Items that do not
+// fit their section are left out with a warning.
+
+const bankBlank = "_____"
+
+const (
+	bankChoice    = "multiple choice"
+	bankTrueFalse = "true/false"
+	bankShort     = "short answer"
+	bankNumeric   = "numeric"
+	bankMatching  = "matching"
+	bankEssay     = "essay"
+	bankCloze     = "cloze"
+)
+
+// bankFits lists the kinds of item each section type takes; a type not
+// listed takes every kind.
+var bankFits = map[string][]string{
+	"word-match":              {bankMatching},
+	"multiple-choice":         {bankChoice},
+	"true-false":              {bankTrueFalse},
+	"numeric":                 {bankNumeric},
+	"ordering":                {},
+	"cloze":                   {bankShort, bankCloze},
+	"essay":                   {bankEssay, bankShort},
+	"diagram":                 {},
+	"word-problem":            {bankChoice, bankShort, bankEssay},
+	"quiz":                    {bankChoice, bankShort, bankEssay},
+	"reading-comprehension":   {bankChoice, bankShort, bankEssay},
+	"comprehension-questions": {bankChoice, bankShort, bankEssay},
+	"passage-completion":      {},
+	"stimulus-groups":         {},
+}
+
+// bankItemSt is one item read from a question bank. A missing word item
+// has text after its blank; a cloze item has its blanks in its question. An
+// item of a kind no section asks has no question and fits nowhere.
+type bankItemSt struct {
+	kind     string
+	title    string
+	category string
+	line     uint
+	before   string
+	after    string
+	question *QuestionsSt
+	pairs    [][2]string
+}
+
+// bankAnswerSt is one of an item's answers, worth weight percent.
+type bankAnswerSt struct {
+	mark     byte
+	weight   float64
+	text     string
+	feedback []string
+}
+
+func (g bankItemSt) name() string {
+	if g.title != "" {
+		return g.title
+	}
+	if g.after != "" {
+		return g.before + " " + bankBlank + " " + g.after
+	}
+	return g.before
+}
+
+// fits reports whether the item can be a question of sectionType.
+func (g bankItemSt) fits(sectionType string) bool {
+	kinds, found := bankFits[sectionType]
+	switch {
+	case g.question == nil:
+		return false
+	case g.kind == bankCloze:
+		return sectionType == "cloze"
+	case !found:
+		return true
+	case sectionType == "cloze" && g.kind == bankShort && g.after == "":
+		return false
+	}
+	for _, kind := range kinds {
+		if kind == g.kind {
+			return true
+		}
+	}
+	return false
+}
+
+// questionFor makes the item a question of sectionType, its blank filled in
+// the way the section shows it.
+func (g bankItemSt) questionFor(sectionType string) *QuestionsSt {
+	q := *g.question
+	blank := ""
+	switch {
+	case g.kind == bankCloze:
+		return &q
+	case g.after == "":
+	case sectionType == "cloze":
+		blank = fmt.Sprintf("{{%s}}", q.Answers.fixMissing().Values()[0])
+		q.Answers = WordsSt{}
+	default:
+		blank = bankBlank
+	}
+	q.Question = NLStringSt{strings.TrimSpace(strings.TrimSpace(g.before+" "+blank) + " " + g.after)}
+	return &q
+}
+
+// bankChoiceQuestion makes a multiple choice question, whose answer is the
+// choice earning the most credit, or a select all one whose answers are the
+// choices earning any.
+func bankChoiceQuestion(answers []bankAnswerSt, selectAll bool) *QuestionsSt {
+	q := &QuestionsSt{
+		Choices:  WordsSt{List: arraylist.New[string]()},
+		Feedback: WordsSt{List: arraylist.New[string]()},
+	}
+	correct, best := make([]uint, 0), 0.0
+	for ai, answer := range answers {
+		q.Choices.Add(answer.text)
+		q.Feedback.Add(strings.Join(answer.feedback, " "))
+		if answer.weight > 0 {
+			correct = append(correct, uint(ai+1))
+		}
+		if answer.weight > best {
+			q.Answer, best = uint(ai+1), answer.weight
+		}
+	}
+	if selectAll && len(correct) > 1 {
+		q.Answer, q.Correct = 0, correct
+	}
+	return q
+}
+
+func bankShortQuestion(answers []bankAnswerSt) *QuestionsSt {
+	q := &QuestionsSt{Answers: WordsSt{List: arraylist.New[string]()}}
+	for _, answer := range answers {
+		if answer.weight > 0 {
+			q.Answers.Add(answer.text)
+		}
+	}
+	return q
+}
+
+// bankTrueFalseQuestion keeps the feedback of each answer with the True
+// and False choices true-false sections give the question.
+func bankTrueFalseQuestion(answers []bankAnswerSt) *QuestionsSt {
+	q := &QuestionsSt{}
+	feedback := []string{"", ""}
+	for _, answer := range answers {
+		isTrue := strings.EqualFold(answer.text, "true")
+		feedback[ternary(isTrue, 0, 1)] = strings.Join(answer.feedback, " ")
+		if answer.weight > 0 {
+			q.True = isTrue
+		}
+	}
+	if feedback[0] != "" || feedback[1] != "" {
+		q.Feedback = WordsSt{List: arraylist.New(feedback...)}
+	}
+	return q
+}
+
+// bankLines trims the lines of text read from HTML, leaving out blank ones.
+func bankLines(text string) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\u00a0", " "), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func bankBool(cell string) (bool, error) {
+	switch strings.ToLower(cell) {
+	case "", "no", "n", "false", "f", "0":
+		return false, nil
+	case "yes", "y", "true", "t", "1", "x":
+		return true, nil
+	}
+	return false, fmt.Errorf("%q is neither yes nor no", cell)
+}
+
+// bankList splits a cell at commas and semicolons.
+func bankList(cell string) []string {
+	list := make([]string, 0)
+	for _, part := range strings.FieldsFunc(cell, func(r rune) bool { return r == ',' || r == ';' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+// bankPool returns the questions, or for word-match sections the words, the
+// items make in a section of sectionType, and the items left out.
+func bankPool(items []bankItemSt, sectionType string) ([]*QuestionsSt, [][2]string, []bankItemSt) {
+	questions, pairs, left := make([]*QuestionsSt, 0), make([][2]string, 0), make([]bankItemSt, 0)
+	for _, item := range items {
+		switch {
+		case !item.fits(sectionType):
+			left = append(left, item)
+		case item.kind == bankMatching:
+			pairs = append(pairs, item.pairs...)
+		default:
+			questions = append(questions, item.questionFor(sectionType))
+		}
+	}
+	return questions, pairs, left
+}
+
+// bankIncludeSt is a section's list of include files in one bank format.
+type bankIncludeSt struct {
+	key    string
+	format string
+	files  WordsSt
+	read   func(string) ([]bankItemSt, error)
+}
+
+func (section JSONSectionSt) bankIncludes() []bankIncludeSt {
+	return []bankIncludeSt{
+		{"includeGift", "GIFT", section.IncludeGift, readGift},
+		{"includeMoodle", "Moodle XML", section.IncludeMoodle, readMoodle},
+		{"includeQTI", "QTI", section.IncludeQTI, readQTI},
+		{"includeMarkdown", "Markdown", section.IncludeMarkdown, readMarkdown},
+	}
+}
+
+func processBankInclude(section JSONSectionSt, assetdir string, bank bankIncludeSt) {
+	bank.files.Each(
+		func(_ int, inc string) {
+			filePath := assetdir + "/" + inc
+			items, err := bank.read(filePath)
+			if err != nil {
+				log.Printf("Unable to load %s include file %s, %v\n", bank.format, filePath, err)
+				return
+			}
+			addBankItems(section, filePath, items)
+		},
+	)
+}
+
+// addBankItems adds the items read from filePath that fit the section to its
+// questions or words, warning of those left out.
+func addBankItems(section JSONSectionSt, filePath string, items []bankItemSt) {
+	questions, pairs, left := bankPool(items, section.Type)
+	for _, item := range left {
+		log.Printf("Warning: %s:%d: %s item %q does not fit the %s section %s, left out\n",
+			filePath, item.line, item.kind, item.name(), section.Type, section.SectionTitle)
+	}
+	section.Questions.Add(questions...)
+	for _, pair := range pairs {
+		section.Words.Put(NLStringSt{pair[0]}, NLStringSt{pair[1]})
+	}
+}
+
+// includeJSON is the question as an include file gives it.
+func (q *QuestionsSt) includeJSON() map[string]any {
+	out := map[string]any{"question": q.Question.string}
+	for key, words := range map[string]WordsSt{
+		"choices": q.Choices, "answers": q.Answers, "feedback": q.Feedback,
+	} {
+		if words.fixMissing().Size() != 0 {
+			out[key] = words.Values()
+		}
+	}
+	switch {
+	case len(q.Correct) != 0:
+		out["correct"] = q.Correct
+	case q.Answer != 0:
+		out["answer"] = q.Answer
+	}
+	if q.True {
+		out["true"] = true
+	}
+	if q.Value != nil {
+		out["value"] = *q.Value
+	}
+	if q.Tolerance != nil && q.Tolerance.Amount != 0 {
+		out["tolerance"] = ternary[any](q.Tolerance.Percent,
+			numberString(q.Tolerance.Amount)+"%", q.Tolerance.Amount)
+	}
+	if q.Units != "" {
+		out["units"] = q.Units
+	}
+	return out
+}
+
+// bankSlug makes name fit for a file name.
+func bankSlug(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name),
+		func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		},
+	)
+	return strings.Join(fields, "-")
+}
+
+// writeBankIncludes writes the items as include files in outDir, one for
+// each category and kind named after them, with matching pairs as words for
+// word-match sections. It returns the files written.
+func writeBankIncludes(items []bankItemSt, outDir, name string) ([]string, error) {
+	files := make([]string, 0)
+	contents := map[string]any{}
+	for _, item := range items {
+		if item.question == nil {
+			continue
+		}
+		filePath := filepath.Join(outDir, bankSlug(ternary(item.category != "", item.category, name)+
+			" "+item.kind)+".json")
+		if _, found := contents[filePath]; !found {
+			files = append(files, filePath)
+			contents[filePath] = ternary[any](item.kind == bankMatching,
+				map[string]string{}, []map[string]any{})
+		}
+		switch content := contents[filePath].(type) {
+		case map[string]string:
+			for _, pair := range item.pairs {
+				content[pair[0]] = pair[1]
+			}
+		case []map[string]any:
+			contents[filePath] = append(content, item.questionFor("").includeJSON())
+		}
+	}
+
+	for _, filePath := range files {
+		data := new(bytes.Buffer)
+		encoder := json.NewEncoder(data)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(contents[filePath]); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filePath, data.Bytes(), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,202 @@
+package testparts
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+)
+
+// A blueprint describes the questions every student's copy of a section
+// must contain, such as "2 easy fractions, 3 medium decimals". A question
+// fills a row when it has the row's difficulty and all of its tags; a row
+// without a difficulty or tags accepts any question. When questionsOntest
+// is larger than the blueprint the remaining questions are picked freely.
+
+type BlueprintRowSt struct {
+	Count      uint    `json:"count"`
+	Difficulty string  `json:"difficulty"`
+	Tags       WordsSt `json:"tags"`
+}
+
+type BlueprintSt []BlueprintRowSt
+
+func (row BlueprintRowSt) matches(q *QuestionsSt) bool {
+	if row.Difficulty != "" && !strings.EqualFold(row.Difficulty, q.Difficulty) {
+		return false
+	}
+	if row.Tags.List == nil {
+		return true
+	}
+	tags := q.Tags.fixMissing()
+	return row.Tags.All(
+		func(_ int, tag string) bool {
+			return tags.Any(
+				func(_ int, qTag string) bool {
+					return strings.EqualFold(tag, qTag)
+				},
+			)
+		},
+	)
+}
+
+func (row BlueprintRowSt) String() string {
+	words := []string{fmt.Sprint(row.Count)}
+	if row.Difficulty != "" {
+		words = append(words, row.Difficulty)
+	}
+	if row.Tags.List != nil && row.Tags.Size() != 0 {
+		words = append(words, strings.Join(row.Tags.Values(), "+"))
+	}
+	return strings.Join(words, " ")
+}
+
+func (b BlueprintSt) total() uint {
+	total := uint(0)
+	for _, row := range b {
+		total += row.Count
+	}
+	return total
+}
+
+// slots lists one row per question on the test, padding with free picks up
+// to numQuest.
+func (b BlueprintSt) slots(numQuest uint) []BlueprintRowSt {
+	slots := make([]BlueprintRowSt, 0, numQuest)
+	for _, row := range b {
+		for i := uint(0); i < row.Count; i++ {
+			slots = append(slots, row)
+		}
+	}
+	for uint(len(slots)) < numQuest {
+		slots = append(slots, BlueprintRowSt{Count: 1})
+	}
+	return slots
+}
+
+// check returns an error describing why pool cannot fill numQuest
+// questions laid out by the blueprint, or nil if it can.
+func (b BlueprintSt) check(pool []*QuestionsSt, numQuest uint) error {
+	if numQuest < b.total() {
+		return fmt.Errorf("the blueprint needs %d questions but questionsOntest is %d",
+			b.total(), numQuest)
+	}
+	for _, row := range b {
+		found := uint(0)
+		for _, q := range pool {
+			if row.matches(q) {
+				found++
+			}
+		}
+		if found < row.Count {
+			return fmt.Errorf("blueprint row %q needs %d questions but the pool only has %d",
+				row.String(), row.Count, found)
+		}
+	}
+
+	slots := b.slots(numQuest)
+	if filled := blueprintMatch(slots, pool); filled < len(slots) {
+		return fmt.Errorf("the blueprint needs %d different questions but the pool can only fill %d of them",
+			len(slots), filled)
+	}
+
+	// Every required question is on each copy, so they must all fit in
+	// slots at once as well.
+	required := slices.DeleteFunc(slices.Clone(pool), func(q *QuestionsSt) bool { return !q.Required })
+	if len(required) > len(slots) {
+		return fmt.Errorf("%d questions are required but questionsOntest is %d", len(required), len(slots))
+	}
+	for _, q := range required {
+		if !slices.ContainsFunc(slots, func(slot BlueprintRowSt) bool { return slot.matches(q) }) {
+			return fmt.Errorf("the required question %q fits no blueprint row", q.Question.string)
+		}
+	}
+	if placed := blueprintMatch(slots, required); placed < len(required) {
+		return fmt.Errorf("the blueprint rows only have room for %d of the %d required questions",
+			placed, len(required))
+	}
+	return nil
+}
+
+// blueprintMatch returns how many slots can be filled at once, each by a
+// different question, using augmenting paths.
+func blueprintMatch(slots []BlueprintRowSt, pool []*QuestionsSt) int {
+	filledBy := make([]int, len(pool))
+	for i := range filledBy {
+		filledBy[i] = -1
+	}
+
+	var assign func(slot int, seen []bool) bool
+	assign = func(slot int, seen []bool) bool {
+		for qi, q := range pool {
+			if seen[qi] || !slots[slot].matches(q) {
+				continue
+			}
+			seen[qi] = true
+			if filledBy[qi] < 0 || assign(filledBy[qi], seen) {
+				filledBy[qi] = slot
+				return true
+			}
+		}
+		return false
+	}
+
+	filled := 0
+	for slot := range slots {
+		if assign(slot, make([]bool, len(pool))) {
+			filled++
+		}
+	}
+	return filled
+}
+
+// BlueprintSet picks numQuest questions laid out by the blueprint. Each slot
+// takes a required question if one fits, otherwise one of the least used,
+// as long as the remaining slots can still be filled and still hold every
+// required question not yet placed.
+func (q QuestionSetSt) BlueprintSet(blueprint BlueprintSt, numQuest uint,
+	keepOrder bool, rnd *rand.Rand) QuestionSetSt {
+	pool := q.Values()
+	slots := blueprint.slots(numQuest)
+	newSet := arraylist.New[*QuestionsSt]()
+
+	for si, slot := range slots {
+		candidates := shuffleSlice(rnd, arraylist.New(pool...).Select(
+			func(_ int, c *QuestionsSt) bool {
+				return slot.matches(c)
+			},
+		).Values())
+		slices.SortStableFunc(candidates, func(a, b *QuestionsSt) int {
+			if a.Required != b.Required {
+				return ternary(a.Required, -1, 1)
+			}
+			return int(a.Used) - int(b.Used)
+		})
+
+		for _, winner := range candidates {
+			rest := slices.DeleteFunc(slices.Clone(pool),
+				func(c *QuestionsSt) bool {
+					return c == winner
+				},
+			)
+			required := slices.DeleteFunc(slices.Clone(rest), func(c *QuestionsSt) bool { return !c.Required })
+			if blueprintMatch(slots[si+1:], rest) == len(slots)-si-1 &&
+				blueprintMatch(slots[si+1:], required) == len(required) {
+				newSet.Add(winner)
+				winner.Used++
+				pool = rest
+				break
+			}
+		}
+	}
+
+	if keepOrder {
+		newSet.Sort(func(a, b *QuestionsSt) int {
+			return q.IndexOf(a) - q.IndexOf(b)
+		})
+		return QuestionSetSt{List: newSet}
+	}
+	return QuestionSetSt{List: arraylist.New(shuffleSlice(rnd, newSet.Values())...)}
+}
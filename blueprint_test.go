@@ -0,0 +1,46 @@
+package testparts
+
+import (
+	"testing"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+)
+
+func TestBlueprintSetKeepsRequired(t *testing.T) {
+	tagged := func(name string, required bool, tags ...string) *QuestionsSt {
+		return &QuestionsSt{Question: NLStringSt{name}, Required: required, Tags: WordsSt{List: arraylist.New(tags...)}}
+	}
+	blueprint := BlueprintSt{
+		{Count: 1, Tags: WordsSt{List: arraylist.New("a")}},
+		{Count: 1, Tags: WordsSt{List: arraylist.New("b")}},
+	}
+	tests := []struct {
+		name     string
+		pool     []*QuestionsSt
+		numQuest uint
+	}{
+		{"either row", []*QuestionsSt{
+			tagged("R1", true, "a", "b"), tagged("R2", true, "a"), tagged("X", false, "b"),
+		}, 2},
+		{"free slot", []*QuestionsSt{
+			tagged("R1", true, "a", "b"), tagged("R2", true, "b"), tagged("X", false, "a"),
+			tagged("Y", false, "a", "b"), tagged("R3", true),
+		}, 3},
+	}
+	for _, tt := range tests {
+		if err := blueprint.check(tt.pool, tt.numQuest); err != nil {
+			t.Fatalf("%s: check: %v", tt.name, err)
+		}
+		for seed := int64(0); seed < 50; seed++ {
+			set := QuestionSetSt{List: arraylist.New(tt.pool...)}.BlueprintSet(blueprint, tt.numQuest, false, newRand(seed))
+			if set.Size() != int(tt.numQuest) {
+				t.Errorf("%s, seed %d: picked %d questions, want %d", tt.name, seed, set.Size(), tt.numQuest)
+			}
+			for _, q := range tt.pool {
+				if q.Required && !set.Contains(q) {
+					t.Errorf("%s, seed %d: required %s left out", tt.name, seed, q.Question.string)
+				}
+			}
+		}
+	}
+}
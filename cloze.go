@@ -0,0 +1,71 @@
+package testparts
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+	"github.com/nwillc/genfuncs"
+)
+
+// A cloze question's text marks each blank with its answer, "Plants make
+// food by {{photosynthesis}}." The blanks become \fillin\ markers and are
+// numbered on each student's copy like questions, sharing their passage's
+// points. With "wordBank" the section lists every answer on a copy, and any
+// extra "word-list" words, shuffled above the passages.
+
+const fillinMarker = `\fillin\`
+
+// makeCloze moves the answers out of a cloze question's blanks, leaving
+// \fillin\ markers.
+func (q *QuestionsSt) makeCloze() {
+	answers := make([]string, 0)
+	text := templateRe.ReplaceAllStringFunc(q.Question.string,
+		func(blank string) string {
+			answers = append(answers, templateRe.FindStringSubmatch(blank)[1])
+			return fillinMarker
+		},
+	)
+	if len(answers) != 0 {
+		q.Question = NLStringSt{text}
+		q.Answers = WordsSt{List: arraylist.New(answers...)}
+	}
+}
+
+// blankPoints returns what blank index of the question is worth, the first
+// blanks taking a point more when its points do not divide.
+func (q *QuestionsSt) blankPoints(index int) uint {
+	blanks := uint(genfuncs.Max(q.Answers.Size(), 1))
+	points := q.Points / blanks
+	if uint(index) < q.Points%blanks {
+		points++
+	}
+	return points
+}
+
+// numberBlanks replaces the \fillin\ markers in text with blanks made by
+// format from the next question numbers.
+func numberBlanks(text, format string, qNum *QuestNumSt) string {
+	parts := strings.Split(text, fillinMarker)
+	for i := 1; i < len(parts); i++ {
+		parts[i] = fmt.Sprintf(format, qNum.NextNumber()) + parts[i]
+	}
+	return strings.Join(parts, "")
+}
+
+// wordBank lists the answers of questions and the extra words, shuffled.
+func wordBank(questions QuestionSetSt, extra WordsSt, rnd *rand.Rand) NLStringListSt {
+	words := make([]NLStringSt, 0)
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			for _, answer := range q.Answers.Values() {
+				words = append(words, NLStringSt{answer})
+			}
+		},
+	)
+	for _, word := range extra.fixMissing().Values() {
+		words = append(words, NLStringSt{word})
+	}
+	return NLStringListSt{List: arraylist.New(shuffleSlice(rnd, words)...)}
+}
@@ -1,6 +1,8 @@
 package testparts
 
 import (
+	"encoding/json"
+	"fmt"
 	"math/rand"
 	"time"
 
@@ -46,6 +48,7 @@ type GormTest struct {
 	Title        string `gorm:"index:idx_test"`
 	Length       uint
 	MinQuestions uint
+	Seed         int64
 	GormClassID  uint
 	Sessions     []GormTestSession
 	Attempts     []GormTestAttempt
@@ -54,21 +57,46 @@ type GormTest struct {
 
 type GormQuestion struct {
 	gorm.Model
-	Required   bool
-	Question   string
-	Points     uint
-	GormTestID uint
-	Choices    []GormQuestionChoice
+	Required    bool
+	Question    string
+	QuestionTeX string
+	Image       string
+	ImageWidth  float64
+	ImageAbove  bool
+	SelectAll   bool
+	KeepChoices bool
+	Points      uint
+	Penalty     float64
+	GormTestID  uint
+	Choices     []GormQuestionChoice
+	Rubric      []GormRubricCriterion
 }
 
 type GormQuestionChoice struct {
 	gorm.Model
 	GormQuestionID uint
 	Choice         string
+	ChoiceTeX      string
+	Image          string
 	Feedback       string
 	Answer         bool
 }
 
+type GormRubricCriterion struct {
+	gorm.Model
+	GormQuestionID uint
+	Name           string
+	Levels         []GormRubricLevel
+}
+
+type GormRubricLevel struct {
+	gorm.Model
+	GormRubricCriterionID uint
+	Level                 string
+	Points                uint
+	Descriptor            string
+}
+
 type GormTestAttempt struct {
 	gorm.Model
 	GormStudentID uint
@@ -89,12 +117,80 @@ type GormTestSession struct {
 }
 
 func (q *GormQuestion) ShuffleChoices() {
+	if q.KeepChoices {
+		return
+	}
 	rand.Shuffle(len(q.Choices),
 		func(i, j int) {
 			q.Choices[i], q.Choices[j] = q.Choices[j], q.Choices[i]
 		})
 }
 
+// Score returns the points earned by picking chosen, which is empty when
+// the question was left blank. Only exactly the right choices earn points.
+func (q *GormQuestion) Score(chosen []*GormQuestionChoice) float64 {
+	if len(chosen) == 0 {
+		return 0
+	}
+	right := 0
+	for _, choice := range q.Choices {
+		if choice.Answer {
+			right++
+		}
+	}
+	for _, choice := range chosen {
+		if !choice.Answer {
+			return -q.Penalty
+		}
+	}
+	return ternary(len(chosen) == right, float64(q.Points), -q.Penalty)
+}
+
+// ScoreRubric records the level, counting from zero, the student reached on
+// each of the question's rubric criteria in the attempt's answers and
+// updates the attempt's score, replacing any earlier scores of the question.
+func (a *GormTestAttempt) ScoreRubric(q *GormQuestion, levels []int) error {
+	if len(levels) != len(q.Rubric) {
+		return fmt.Errorf("%d levels for %d rubric criteria", len(levels), len(q.Rubric))
+	}
+	answers := AttemptAnswersSt{}
+	if len(a.Answers) != 0 {
+		if err := json.Unmarshal(a.Answers, &answers); err != nil {
+			return err
+		}
+	}
+	if answers.Rubrics == nil {
+		answers.Rubrics = map[uint][]RubricScoreSt{}
+	}
+
+	scores := make([]RubricScoreSt, 0, len(levels))
+	for ci, level := range levels {
+		criterion := q.Rubric[ci]
+		if level < 0 || level >= len(criterion.Levels) {
+			return fmt.Errorf("criterion %s has no level %d", criterion.Name, level)
+		}
+		scores = append(scores, RubricScoreSt{
+			Criterion: criterion.Name,
+			Level:     criterion.Levels[level].Level,
+			Points:    criterion.Levels[level].Points,
+		})
+	}
+	for _, score := range answers.Rubrics[q.ID] {
+		a.Score -= float64(score.Points)
+	}
+	for _, score := range scores {
+		a.Score += float64(score.Points)
+	}
+	answers.Rubrics[q.ID] = scores
+
+	data, err := json.Marshal(answers)
+	if err != nil {
+		return err
+	}
+	a.Answers = data
+	return nil
+}
+
 func (t *GormTest) ShuffleQuestions() {
 	rand.Shuffle(len(t.Questions),
 		func(i, j int) {
@@ -0,0 +1,84 @@
+package testparts
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGormQuestionScore(t *testing.T) {
+	single := &GormQuestion{Points: 4, Penalty: 1, Choices: []GormQuestionChoice{
+		{Choice: "a", Answer: true}, {Choice: "b"}, {Choice: "c"},
+	}}
+	selectAll := &GormQuestion{Points: 6, Penalty: 2, SelectAll: true, Choices: []GormQuestionChoice{
+		{Choice: "a", Answer: true}, {Choice: "b", Answer: true}, {Choice: "c"},
+	}}
+	tests := []struct {
+		name   string
+		q      *GormQuestion
+		chosen []int
+		want   float64
+	}{
+		{"right", single, []int{0}, 4},
+		{"wrong", single, []int{1}, -1},
+		{"blank", single, nil, 0},
+		{"all right", selectAll, []int{0, 1}, 6},
+		{"some right", selectAll, []int{1}, -2},
+		{"right and wrong", selectAll, []int{0, 1, 2}, -2},
+		{"blank select all", selectAll, []int{}, 0},
+	}
+	for _, tt := range tests {
+		chosen := make([]*GormQuestionChoice, 0, len(tt.chosen))
+		for _, ci := range tt.chosen {
+			chosen = append(chosen, &tt.q.Choices[ci])
+		}
+		if got := tt.q.Score(chosen); got != tt.want {
+			t.Errorf("%s: Score = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScoreRubric(t *testing.T) {
+	levels := func(points ...uint) []GormRubricLevel {
+		out := make([]GormRubricLevel, 0, len(points))
+		for li, value := range points {
+			out = append(out, GormRubricLevel{Level: string(rune('A' + li)), Points: value})
+		}
+		return out
+	}
+	q := &GormQuestion{Rubric: []GormRubricCriterion{
+		{Name: "Ideas", Levels: levels(4, 2, 0)},
+		{Name: "Grammar", Levels: levels(3, 1, 0)},
+	}}
+	q.ID = 7
+	tests := []struct {
+		name   string
+		levels []int
+		score  float64
+		fails  bool
+	}{
+		{"best", []int{0, 0}, 12, false},
+		{"marked again", []int{1, 2}, 7, false},
+		{"too few levels", []int{1}, 7, true},
+		{"no such level", []int{0, 3}, 7, true},
+		{"lowest", []int{2, 2}, 5, false},
+	}
+	// The attempt starts with 5 points from other questions, and each
+	// marking replaces the last one.
+	attempt := &GormTestAttempt{Score: 5}
+	for _, tt := range tests {
+		err := attempt.ScoreRubric(q, tt.levels)
+		if (err != nil) != tt.fails {
+			t.Errorf("%s: ScoreRubric error = %v, want an error %v", tt.name, err, tt.fails)
+		}
+		if attempt.Score != tt.score {
+			t.Errorf("%s: attempt score = %v, want %v", tt.name, attempt.Score, tt.score)
+		}
+	}
+	answers := AttemptAnswersSt{}
+	if err := json.Unmarshal(attempt.Answers, &answers); err != nil {
+		t.Fatal(err)
+	}
+	if scores := answers.Rubrics[q.ID]; len(scores) != 2 || scores[0].Level != "C" || scores[1].Points != 0 {
+		t.Errorf("recorded rubric scores = %+v", scores)
+	}
+}
@@ -1,6 +1,7 @@
 package testparts
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"time"
@@ -31,7 +32,7 @@ func OpenCockroachDB(dsn string, autoMigrate bool) (*gorm.DB, error) {
 	// only need to run this when the schema changes
 	if autoMigrate {
 		err = db.AutoMigrate(&GormTest{}, &GormQuestion{}, &GormQuestionChoice{},
-			GromPreference{}, GormClass{}, &GormStudent{}, GormTestAttempt{},
+			&GormRubricCriterion{}, &GormRubricLevel{}, GromPreference{}, GormClass{}, &GormStudent{}, GormTestAttempt{},
 			GormTestSession{})
 	}
 
@@ -93,3 +94,36 @@ func (p *Preference) Del(key string) error {
 
 	return db.Delete(&GromPreference{Key: key}).Error
 }
+
+// ---- Rubric scores ----
+
+// RecordRubricScore marks an attempt's answer to a rubric question with the
+// level, counting from zero, reached on each of its criteria, and saves the
+// attempt with its new score.
+func RecordRubricScore(dsn string, attemptID, questionID uint, levels []int) error {
+	db, err := OpenCockroachDB(dsn, false)
+	if err != nil {
+		return err
+	}
+	defer CloseCockroachDB(db)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		attempt, question := GormTestAttempt{}, GormQuestion{}
+		if err := tx.First(&attempt, attemptID).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Preload("Rubric", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			Preload("Rubric.Levels", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			First(&question, questionID).Error; err != nil {
+			return err
+		}
+		if question.GormTestID != attempt.GormTestID {
+			return fmt.Errorf("question %d is not on the test of attempt %d", questionID, attemptID)
+		}
+		if err := attempt.ScoreRubric(&question, levels); err != nil {
+			return err
+		}
+		return tx.Save(&attempt).Error
+	})
+}
@@ -0,0 +1,243 @@
+package testparts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Test files are composed before they are validated:
+//
+//   - "extends" names a base test file whose top-level settings, such as
+//     school, logo, grade and classes, apply unless the test sets them.
+//   - a section holding "ref": "library.yaml#fractions" is replaced by the
+//     section with that "id" in the library file, with every other field
+//     of the referencing section overriding the library's. A ref without
+//     "#id" takes the whole library file as the section.
+//   - "sectionDefaults" holds fields every section gets unless it sets them.
+//
+// All paths are relative to the asset directory.
+
+// resolve applies "extends" and section refs. The returned spec is nil when
+// composition cannot continue.
+func (sf *specFileSt) resolve(assetdir string, chain []string) (*specFileSt, ValidationErrorsSt) {
+	doc := map[string]any{}
+	if err := decodeDoc(sf.data, &doc); err != nil {
+		return sf, nil
+	}
+
+	absPath, _ := filepath.Abs(sf.path)
+	if slices.Contains(chain, absPath) {
+		return nil, ValidationErrorsSt{sf.errorAt("", "%s extends or references itself", sf.path)}
+	}
+	chain = append(chain, absPath)
+
+	composed := &specFileSt{
+		path:      sf.path,
+		positions: copyPositions(sf.positions),
+	}
+	errs := ValidationErrorsSt{}
+
+	if base, found := doc["extends"].(string); found {
+		baseSpec, baseErrs := composed.loadComposed(assetdir, base, "/extends", chain)
+		errs = append(errs, baseErrs...)
+		if baseSpec != nil {
+			composed.extend(doc, baseSpec)
+		}
+		delete(doc, "extends")
+	}
+
+	sections, _ := doc["sections"].([]any)
+	for si, item := range sections {
+		section, _ := item.(map[string]any)
+		ref, found := section["ref"].(string)
+		if !found {
+			continue
+		}
+
+		pointer := fmt.Sprintf("/sections/%d", si)
+		libSpec, libPointer, refErrs := composed.resolveRef(assetdir, ref, pointer+"/ref", chain)
+		errs = append(errs, refErrs...)
+		if libSpec == nil {
+			continue
+		}
+
+		libDoc := map[string]any{}
+		decodeDoc(libSpec.data, &libDoc)
+		merged := libDoc
+		if libPointer != "" {
+			libSections, _ := libDoc["sections"].([]any)
+			merged = libSections[sectionIndex(libPointer)].(map[string]any)
+		}
+
+		original := copyPositions(composed.positions)
+		graftPositions(composed.positions, pointer, libSpec.positions, libPointer)
+		for key, value := range section {
+			if key == "ref" {
+				continue
+			}
+			merged[key] = value
+			keyPointer := "/" + pointerEscaper.Replace(key)
+			graftPositions(composed.positions, pointer+keyPointer, original, pointer+keyPointer)
+		}
+		sections[si] = merged
+	}
+
+	composed.data, _ = json.Marshal(doc)
+	return composed, errs
+}
+
+// extend copies into doc every top-level setting of the base it does not
+// set itself. Section defaults are merged field by field.
+func (sf *specFileSt) extend(doc map[string]any, base *specFileSt) {
+	baseDoc := map[string]any{}
+	decodeDoc(base.data, &baseDoc)
+
+	for key, value := range baseDoc {
+		keyPointer := "/" + pointerEscaper.Replace(key)
+		if _, found := doc[key]; !found {
+			doc[key] = value
+			graftPositions(sf.positions, keyPointer, base.positions, keyPointer)
+			continue
+		}
+
+		defaults, isDefaults := doc[key].(map[string]any)
+		baseDefaults, _ := value.(map[string]any)
+		if key != "sectionDefaults" || !isDefaults {
+			continue
+		}
+		for field, fieldValue := range baseDefaults {
+			if _, found := defaults[field]; !found {
+				defaults[field] = fieldValue
+				fieldPointer := keyPointer + "/" + pointerEscaper.Replace(field)
+				graftPositions(sf.positions, fieldPointer, base.positions, fieldPointer)
+			}
+		}
+	}
+}
+
+// resolveRef loads the library a section ref names. The returned pointer
+// locates the referenced section within the library, empty when the whole
+// file is the section.
+func (sf *specFileSt) resolveRef(assetdir, ref, pointer string,
+	chain []string) (*specFileSt, string, ValidationErrorsSt) {
+	fileName, id, _ := strings.Cut(ref, "#")
+	libSpec, errs := sf.loadComposed(assetdir, fileName, pointer, chain)
+	if libSpec == nil {
+		return nil, "", errs
+	}
+	libSpec.applySectionDefaults()
+
+	libDoc := map[string]any{}
+	if err := decodeDoc(libSpec.data, &libDoc); err != nil {
+		return nil, "", append(errs, libSpec.errorAt("", "a section library must be an object"))
+	}
+	if id == "" {
+		return libSpec, "", errs
+	}
+
+	libSections, _ := libDoc["sections"].([]any)
+	for li, item := range libSections {
+		if section, _ := item.(map[string]any); section["id"] == id {
+			return libSpec, fmt.Sprintf("/sections/%d", li), errs
+		}
+	}
+	return nil, "", append(errs, sf.errorAt(pointer, "no section with id %q in %s", id, fileName))
+}
+
+func (sf *specFileSt) loadComposed(assetdir, fileName, pointer string,
+	chain []string) (*specFileSt, ValidationErrorsSt) {
+	filePath := assetdir + "/" + fileName
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, ValidationErrorsSt{sf.errorAt(pointer, "%s does not exist", fileName)}
+	}
+
+	spec, err := loadSpecFile(filePath)
+	if syntaxErrs, ok := err.(ValidationErrorsSt); ok {
+		return nil, syntaxErrs
+	} else if err != nil {
+		return nil, ValidationErrorsSt{sf.errorAt(pointer, "unable to load %s: %v", fileName, err)}
+	}
+	return spec.resolve(assetdir, chain)
+}
+
+// applySectionDefaults fills in each section's missing fields from
+// "sectionDefaults" and then drops the defaults from the document.
+func (sf *specFileSt) applySectionDefaults() {
+	doc := map[string]any{}
+	if err := decodeDoc(sf.data, &doc); err != nil {
+		return
+	}
+	defaults, found := doc["sectionDefaults"].(map[string]any)
+	if !found {
+		return
+	}
+	delete(doc, "sectionDefaults")
+
+	sections, _ := doc["sections"].([]any)
+	for si, item := range sections {
+		section, isSection := item.(map[string]any)
+		if !isSection {
+			continue
+		}
+		for key, value := range defaults {
+			if _, found := section[key]; !found {
+				section[key] = value
+				keyPointer := "/" + pointerEscaper.Replace(key)
+				graftPositions(sf.positions, fmt.Sprintf("/sections/%d%s", si, keyPointer),
+					sf.positions, "/sectionDefaults"+keyPointer)
+			}
+		}
+	}
+
+	sf.data, _ = json.Marshal(doc)
+}
+
+func copyPositions(positions map[string]specPosSt) map[string]specPosSt {
+	newPositions := make(map[string]specPosSt, len(positions))
+	for pointer, pos := range positions {
+		newPositions[pointer] = pos
+	}
+	return newPositions
+}
+
+// graftPositions replaces the positions under to in dst with those under
+// from in src.
+func graftPositions(dst map[string]specPosSt, to string,
+	src map[string]specPosSt, from string) {
+	grafted := map[string]specPosSt{}
+	for pointer, pos := range src {
+		if rest, found := strings.CutPrefix(pointer, from); found &&
+			(rest == "" || strings.HasPrefix(rest, "/")) {
+			grafted[to+rest] = pos
+		}
+	}
+
+	for pointer := range dst {
+		if rest, found := strings.CutPrefix(pointer, to); found &&
+			(rest == "" || strings.HasPrefix(rest, "/")) {
+			delete(dst, pointer)
+		}
+	}
+	for pointer, pos := range grafted {
+		dst[pointer] = pos
+	}
+}
+
+func sectionIndex(pointer string) int {
+	index := 0
+	fmt.Sscanf(pointer, "/sections/%d", &index)
+	return index
+}
+
+// decodeDoc unmarshals a document keeping numbers as json.Number, so large
+// integers such as seeds survive being re-encoded.
+func decodeDoc(data []byte, doc any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(doc)
+}
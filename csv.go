@@ -0,0 +1,339 @@
+package testparts
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+)
+
+// "includeCsv" reads questions from spreadsheets saved as comma separated
+// values or, for .tsv files, tab separated ones. Each include names its
+// "file" and maps columns, given by their heading in the first row or by
+// their letter, to question fields:
+//
+//	question    the question
+//	choices     the choice columns in order, empty cells left out
+//	answer      the right choice's letter, number from 1 or text, several
+//	            separated by commas for select all questions; "answerAs"
+//	            picks one reading when a choice looks like a letter or number
+//	required    yes when the question is on every copy
+//	tags        the question's tags, separated by commas
+//	word        and "definition" make the words of word-match sections
+//
+// Without choices the answer is what the section type takes: true or false,
+// a number, or an answer. "noHeadings" reads the first row as a question
+// and "delimiter" sets another separator. Rows that cannot be read are
+// reported with their row number and left out.
+
+// CsvIncludeSt maps the columns of a spreadsheet to question fields.
+type CsvIncludeSt struct {
+	File       string   `json:"file"`
+	Delimiter  string   `json:"delimiter"`
+	NoHeadings bool     `json:"noHeadings"`
+	Question   string   `json:"question"`
+	Choices    []string `json:"choices"`
+	Answer     string   `json:"answer"`
+	AnswerAs   string   `json:"answerAs"`
+	Required   string   `json:"required"`
+	Tags       string   `json:"tags"`
+	Word       string   `json:"word"`
+	Definition string   `json:"definition"`
+}
+
+// csvColumnsSt holds the index of each mapped column, -1 when not mapped.
+type csvColumnsSt struct {
+	question, answer, required, tags, word, definition int
+	choices                                            []int
+}
+
+// csvRowSt is one row of a spreadsheet, numbered by the line it starts on.
+type csvRowSt struct {
+	file  string
+	row   uint
+	cells []string
+}
+
+func (r csvRowSt) cell(column int) string {
+	if column < 0 || column >= len(r.cells) {
+		return ""
+	}
+	return strings.TrimSpace(r.cells[column])
+}
+
+func (r csvRowSt) errorAt(column int, format string, a ...any) ValidationErrorSt {
+	return ValidationErrorSt{
+		File: r.file, Line: r.row, Column: uint(max(column, 0) + 1),
+		Message: fmt.Sprintf(format, a...),
+	}
+}
+
+// csvColumn finds the column named by its heading, ignoring case, or by its
+// letter, A for the first, which must be one of the headed columns.
+func csvColumn(name string, headings []string) (int, bool) {
+	name = strings.TrimSpace(name)
+	for hi, heading := range headings {
+		if strings.EqualFold(strings.TrimSpace(heading), name) {
+			return hi, true
+		}
+	}
+	column := 0
+	for _, r := range strings.ToUpper(name) {
+		if r < 'A' || r > 'Z' {
+			return 0, false
+		}
+		column = column*26 + int(r-'A') + 1
+	}
+	return column - 1, column != 0 && (headings == nil || column <= len(headings))
+}
+
+// columns finds the mapped columns among the headings.
+func (inc CsvIncludeSt) columns(headings []string) (csvColumnsSt, error) {
+	find := func(field, name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		column, found := csvColumn(name, headings)
+		if !found {
+			return -1, fmt.Errorf("the %s column %q is neither a heading nor a column letter", field, name)
+		}
+		return column, nil
+	}
+
+	columns := csvColumnsSt{}
+	var err error
+	for _, field := range []struct {
+		name   string
+		value  string
+		column *int
+	}{
+		{"question", inc.Question, &columns.question},
+		{"answer", inc.Answer, &columns.answer},
+		{"required", inc.Required, &columns.required},
+		{"tags", inc.Tags, &columns.tags},
+		{"word", inc.Word, &columns.word},
+		{"definition", inc.Definition, &columns.definition},
+	} {
+		if *field.column, err = find(field.name, field.value); err != nil {
+			return columns, err
+		}
+	}
+	for _, choice := range inc.Choices {
+		column, err := find("choice", choice)
+		if err != nil {
+			return columns, err
+		}
+		columns.choices = append(columns.choices, column)
+	}
+
+	switch {
+	case (columns.word < 0) != (columns.definition < 0):
+		return columns, fmt.Errorf("a word column needs a definition column and a definition column a word column")
+	case columns.word < 0 && columns.question < 0:
+		return columns, fmt.Errorf("no question column, or word and definition columns, are mapped")
+	}
+	return columns, nil
+}
+
+// read reads the items of the spreadsheet at filePath for a section of
+// sectionType, with the rows that cannot be read.
+func (inc CsvIncludeSt) read(filePath, sectionType string) ([]bankItemSt, ValidationErrorsSt, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comma = ternary(strings.EqualFold(filepath.Ext(filePath), ".tsv"), '\t', ',')
+	if inc.Delimiter != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(inc.Delimiter)
+	}
+	reader.LazyQuotes = reader.Comma == '\t'
+	reader.FieldsPerRecord = -1
+
+	items, rowErrs := make([]bankItemSt, 0), ValidationErrorsSt{}
+	var columns *csvColumnsSt
+	for {
+		cells, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		// The reader skips blank lines, so rows are numbered by where they start.
+		if parseErr := (&csv.ParseError{}); errors.As(err, &parseErr) && columns != nil {
+			row := csvRowSt{file: filePath, row: uint(parseErr.StartLine)}
+			rowErrs = append(rowErrs, row.errorAt(-1, "%v", parseErr.Err))
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		row := csvRowSt{file: filePath, row: uint(line), cells: cells}
+
+		if columns == nil {
+			found, err := inc.columns(ternary(inc.NoHeadings, nil, cells))
+			if err != nil {
+				return nil, nil, err
+			}
+			if columns = &found; !inc.NoHeadings {
+				continue
+			}
+		}
+		item, rowErr := inc.item(row, *columns, sectionType)
+		switch {
+		case rowErr != nil:
+			rowErrs = append(rowErrs, *rowErr)
+		case item != nil:
+			items = append(items, *item)
+		}
+	}
+	return items, rowErrs, nil
+}
+
+// item makes a row an item, nil for an empty row.
+func (inc CsvIncludeSt) item(row csvRowSt, columns csvColumnsSt, sectionType string) (*bankItemSt, *ValidationErrorSt) {
+	if strings.TrimSpace(strings.Join(row.cells, "")) == "" {
+		return nil, nil
+	}
+	fail := func(column int, format string, a ...any) (*bankItemSt, *ValidationErrorSt) {
+		rowErr := row.errorAt(column, format, a...)
+		return nil, &rowErr
+	}
+	item := &bankItemSt{line: row.row}
+
+	if columns.word >= 0 {
+		word, definition := row.cell(columns.word), row.cell(columns.definition)
+		switch {
+		case word == "":
+			return fail(columns.word, "the word is empty")
+		case definition == "":
+			return fail(columns.definition, "the definition of %q is empty", word)
+		}
+		item.kind, item.title = bankMatching, word
+		item.pairs, item.question = [][2]string{{word, definition}}, &QuestionsSt{}
+		return item, nil
+	}
+
+	item.before = row.cell(columns.question)
+	if item.before == "" {
+		return fail(columns.question, "the question is empty")
+	}
+	answer := row.cell(columns.answer)
+	choices := make([]string, 0)
+	for _, column := range columns.choices {
+		if choice := row.cell(column); choice != "" {
+			choices = append(choices, choice)
+		}
+	}
+
+	switch {
+	case len(choices) != 0:
+		right, found := inc.choiceAnswers(answer, choices)
+		if !found {
+			return fail(columns.answer, "the answer %q is not one of the %d choices", answer, len(choices))
+		}
+		answers := make([]bankAnswerSt, 0, len(choices))
+		for ci, choice := range choices {
+			answers = append(answers, bankAnswerSt{text: choice, weight: ternary(right[ci], 100.0, 0.0)})
+		}
+		item.kind, item.question = bankChoice, bankChoiceQuestion(answers, len(right) > 1)
+	case sectionType == "true-false":
+		isTrue, err := bankBool(answer)
+		if err != nil || answer == "" {
+			return fail(columns.answer, "the answer %q is neither true nor false", answer)
+		}
+		item.kind, item.question = bankTrueFalse, &QuestionsSt{True: isTrue}
+	case sectionType == "numeric":
+		value, err := strconv.ParseFloat(answer, 64)
+		if err != nil {
+			return fail(columns.answer, "the answer %q is not a number", answer)
+		}
+		item.kind, item.question = bankNumeric, &QuestionsSt{Value: &value}
+	case sectionType == "cloze":
+		if !strings.Contains(item.before, "{{") {
+			return fail(columns.question, "the question has no {{blanks}}")
+		}
+		item.kind, item.question = bankCloze, &QuestionsSt{}
+	default:
+		item.kind, item.question = ternary(sectionType == "essay", bankEssay, bankShort), &QuestionsSt{}
+		if answer != "" {
+			item.question.Answers = WordsSt{List: arraylist.New(answer)}
+		}
+	}
+	item.question.Question = NLStringSt{item.before}
+
+	required, err := bankBool(row.cell(columns.required))
+	if err != nil {
+		return fail(columns.required, "%v", err)
+	}
+	item.question.Required = required
+	if tags := bankList(row.cell(columns.tags)); len(tags) != 0 {
+		item.question.Tags = WordsSt{List: arraylist.New(tags...)}
+	}
+	return item, nil
+}
+
+// choiceAnswers finds the right choices the answer names, the whole answer
+// or each of its comma separated parts.
+func (inc CsvIncludeSt) choiceAnswers(answer string, choices []string) (map[int]bool, bool) {
+	if ci, found := inc.choice(answer, choices); found {
+		return map[int]bool{ci: true}, true
+	}
+	right := map[int]bool{}
+	for _, part := range bankList(answer) {
+		ci, found := inc.choice(part, choices)
+		if !found {
+			return nil, false
+		}
+		right[ci] = true
+	}
+	return right, len(right) != 0
+}
+
+// choice finds the choice answer names by its text, letter or number, as
+// answerAs says or whichever fits first.
+func (inc CsvIncludeSt) choice(answer string, choices []string) (int, bool) {
+	if inc.AnswerAs == "" || inc.AnswerAs == "text" {
+		for ci, choice := range choices {
+			if strings.EqualFold(choice, answer) {
+				return ci, true
+			}
+		}
+	}
+	if inc.AnswerAs == "" || inc.AnswerAs == "letter" {
+		if letter := strings.ToUpper(answer); len(letter) == 1 && letter[0] >= 'A' &&
+			int(letter[0]-'A') < len(choices) {
+			return int(letter[0] - 'A'), true
+		}
+	}
+	if inc.AnswerAs == "" || inc.AnswerAs == "index" {
+		if number, err := strconv.Atoi(answer); err == nil && number >= 1 && number <= len(choices) {
+			return number - 1, true
+		}
+	}
+	return 0, false
+}
+
+func ProcessIncludeCsv(section JSONSectionSt, assetdir string) {
+	for _, inc := range section.IncludeCsv {
+		filePath := assetdir + "/" + inc.File
+		items, rowErrs, err := inc.read(filePath, section.Type)
+		if err != nil {
+			log.Printf("Unable to load CSV include file %s, %v\n", filePath, err)
+			continue
+		}
+		for _, rowErr := range rowErrs {
+			log.Printf("Warning: %v, row left out\n", rowErr)
+		}
+		addBankItems(section, filePath, items)
+	}
+}
@@ -0,0 +1,123 @@
+package testparts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCsvRowErrors(t *testing.T) {
+	choices := CsvIncludeSt{Question: "Question", Choices: []string{"A", "B", "C"}, Answer: "Answer"}
+	tests := []struct {
+		name, file, content, sectionType string
+		inc                              CsvIncludeSt
+		items                            int
+		// want lists each row error as line:column message.
+		want []string
+	}{
+		{
+			"choices", "bank.csv",
+			"Question,A,B,C,Answer\n" +
+				"Two plus two?,3,4,5,B\n" +
+				",1,2,3,A\n" +
+				"Three plus three?,5,6,7,D\n" +
+				"\n" +
+				"Four plus four?,7,8,,c\n",
+			"multiple-choice", choices, 1,
+			[]string{
+				"3:1 the question is empty",
+				`4:5 the answer "D" is not one of the 3 choices`,
+				`6:5 the answer "c" is not one of the 2 choices`,
+			},
+		},
+		{
+			"column letters without headings", "bank.tsv",
+			"Is ice cold?\tyes\n" +
+				"Is fire cold?\tmaybe\n" +
+				"\tno\n",
+			"true-false", CsvIncludeSt{NoHeadings: true, Question: "A", Answer: "B"}, 1,
+			[]string{
+				`2:2 the answer "maybe" is neither true nor false`,
+				"3:1 the question is empty",
+			},
+		},
+		{
+			"numbers and required", "bank.csv",
+			"Q,Value,Must\n" +
+				"Pi?,3.14,yes\n" +
+				"E?,two point seven,\n" +
+				"Root two?,1.41,perhaps\n",
+			"numeric", CsvIncludeSt{Question: "Q", Answer: "value", Required: "must"}, 1,
+			[]string{
+				`3:2 the answer "two point seven" is not a number`,
+				"4:3 ",
+			},
+		},
+		{
+			"words", "words.csv",
+			"Word,Meaning\n" +
+				"cell,basic unit\n" +
+				",no word\n" +
+				"wall,\n",
+			"word-match", CsvIncludeSt{Word: "Word", Definition: "Meaning"}, 1,
+			[]string{
+				"3:1 the word is empty",
+				`4:2 the definition of "wall" is empty`,
+			},
+		},
+		{
+			"bad quotes", "bank.csv",
+			"Question,A,B,C,Answer\n" +
+				"Good?,x,y,z,A\n" +
+				"Bad \"quote\" here,x,y,z,A\n",
+			"multiple-choice", choices, 1,
+			[]string{"3:1 "},
+		},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), tt.file)
+		if err := os.WriteFile(file, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		items, rowErrs, err := tt.inc.read(file, tt.sectionType)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(items) != tt.items {
+			t.Errorf("%s: read %d items, want %d", tt.name, len(items), tt.items)
+		}
+		if len(rowErrs) != len(tt.want) {
+			t.Errorf("%s: %d row errors %v, want %d", tt.name, len(rowErrs), rowErrs, len(tt.want))
+			continue
+		}
+		for ri, rowErr := range rowErrs {
+			got := fmt.Sprintf("%d:%d %s", rowErr.Line, rowErr.Column, rowErr.Message)
+			if rowErr.File != file || !strings.HasPrefix(got, tt.want[ri]) {
+				t.Errorf("%s: row error %q in %s, want %q", tt.name, got, rowErr.File, tt.want[ri])
+			}
+		}
+	}
+}
+
+func TestCsvColumns(t *testing.T) {
+	headings := []string{"Question", " Answer ", "Tags"}
+	tests := []struct {
+		inc   CsvIncludeSt
+		fails bool
+	}{
+		{CsvIncludeSt{Question: "question", Answer: "answer", Tags: "C"}, false},
+		{CsvIncludeSt{Question: "A", Answer: "B"}, false},
+		{CsvIncludeSt{Question: "Question", Answer: "D"}, true},
+		{CsvIncludeSt{Question: "Question", Answer: "Missing"}, true},
+		{CsvIncludeSt{Word: "A"}, true},
+		{CsvIncludeSt{Answer: "B"}, true},
+	}
+	for _, tt := range tests {
+		if _, err := tt.inc.columns(headings); (err != nil) != tt.fails {
+			t.Errorf("columns(%+v) error = %v, want an error %v", tt.inc, err, tt.fails)
+		}
+	}
+}
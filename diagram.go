@@ -0,0 +1,98 @@
+package testparts
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	rtfdoc "github.com/abaskin/testparts/rtf-doc"
+	"github.com/daichi-m/go18ds/lists/arraylist"
+)
+
+// A diagram question shows its "image" with numbered "callouts", each the
+// "label" students write for it and where it points, "x" across and "y"
+// down the image as fractions of its width and height. The callouts are
+// numbered in a shuffled order on each copy, unless the section keeps its
+// order, like questions, and share the question's points. With "wordBank"
+// the section lists the labels on a copy, and any extra "word-list" words.
+
+// diagramImageWidth is how much of the line a diagram takes by default.
+const diagramImageWidth = 0.8
+
+type CalloutSt struct {
+	Label string  `json:"label"`
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+}
+
+// position says where on the image the callout is, for output that cannot
+// draw on it.
+func (c CalloutSt) position() string {
+	across := []string{"left", "centre", "right"}[min(int(c.X*3), 2)]
+	down := []string{"top", "middle", "bottom"}[min(int(c.Y*3), 2)]
+	if across == "centre" && down == "middle" {
+		return "centre"
+	}
+	return down + " " + across
+}
+
+// placeCallouts numbers a copy's callouts, shuffled with rnd unless
+// keepOrder, and makes their labels the answers.
+func (q *QuestionsSt) placeCallouts(rnd *rand.Rand, keepOrder bool) {
+	q.Callouts = slices.Clone(q.Callouts)
+	if !keepOrder {
+		q.Callouts = shuffleSlice(rnd, q.Callouts)
+	}
+	labels := make([]string, 0, len(q.Callouts))
+	for _, callout := range q.Callouts {
+		labels = append(labels, callout.Label)
+	}
+	q.Answers = WordsSt{List: arraylist.New(labels...)}
+}
+
+// diagramLatex draws the question's image with its callouts numbered from
+// first on top.
+func (q *QuestionsSt) diagramLatex(first uint32) []string {
+	outStr := []string{
+		`\begin{center}`,
+		`\begin{tikzpicture}`,
+		fmt.Sprintf(`\node[anchor=south west, inner sep=0] (diagram) at (0,0) {%s};`,
+			q.Image.latex(diagramImageWidth)),
+		`\begin{scope}[x={(diagram.south east)}, y={(diagram.north west)}]`,
+	}
+	for ci, callout := range q.Callouts {
+		outStr = append(outStr, fmt.Sprintf(
+			`\node[circle, draw, fill=white, inner sep=1pt, font=\small\bfseries] at (%.3f, %.3f) {%d};`,
+			callout.X, 1-callout.Y, first+uint32(ci)))
+	}
+	return append(outStr, `\end{scope}`, `\end{tikzpicture}`, `\end{center}`)
+}
+
+// diagramBlanks is a numbered line for each callout from first.
+func (q *QuestionsSt) diagramBlanks(first uint32) string {
+	blanks := make([]string, 0, len(q.Callouts))
+	for ci := range q.Callouts {
+		blanks = append(blanks, fmt.Sprintf(`\textbf{%d.}~\rule{4cm}{0.4pt}`, first+uint32(ci)))
+	}
+	return strings.Join(blanks, `\quad `)
+}
+
+// addDiagramRTF adds the question's image and a table with a line for each
+// callout, saying where it is since RTF cannot draw on the image.
+func (doc *RTFDoc) addDiagramRTF(t *rtfdoc.Table, q *QuestionsSt, qNum *QuestNumSt) {
+	q.Image.addRTF(t.AddTableRow().
+		AddDataCell(tableWidth).
+		AddParagraph().
+		SetAlign(rtfdoc.AlignCenter), tableWidth, diagramImageWidth)
+
+	cWidth := t.GetTableCellWidthByRatio(1, 2)
+	for _, callout := range q.Callouts {
+		tr := t.AddTableRow()
+		tr.AddDataCell(cWidth[0]).AddParagraph().
+			AddText(fmt.Sprintf("%d. (%s)", qNum.NextNumber(), callout.position()),
+				12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+		tr.AddDataCell(cWidth[1]).AddParagraph().
+			AddText(strings.Repeat("_", 30), 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+	}
+}
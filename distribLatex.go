@@ -2,16 +2,51 @@ package testparts
 
 import (
 	"fmt"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
 )
 
 func (r *ReadingCompSt) DistribLatex(testTitle string, numTest uint) []string {
 	return questDistrib(r.AllQuestions, r.Title, testTitle, r.Points, numTest)
 }
 
+// DistribLatex charts how often each stimulus was drawn.
+func (s *StimulusGroupsSt) DistribLatex(testTitle string, numTest uint) []string {
+	stimuli := QuestionSetSt{List: arraylist.New[*QuestionsSt]()}
+	for _, stimulus := range s.AllStimuli {
+		stimuli.Add(&QuestionsSt{Question: NLStringSt{stimulus.Title}, Used: stimulus.Used})
+	}
+	return questDistrib(stimuli, s.Title, testTitle, s.Points, numTest)
+}
+
 func (m *MultipleChoiceSt) DistribLatex(testTitle string, numTest uint) []string {
 	return questDistrib(m.AllQuestions, m.Title, testTitle, m.Points, numTest)
 }
 
+func (t *TrueFalseSt) DistribLatex(testTitle string, numTest uint) []string {
+	return questDistrib(t.AllQuestions, t.Title, testTitle, t.Points, numTest)
+}
+
+func (n *NumericSt) DistribLatex(testTitle string, numTest uint) []string {
+	return questDistrib(n.AllQuestions, n.Title, testTitle, n.Points, numTest)
+}
+
+func (o *OrderingSt) DistribLatex(testTitle string, numTest uint) []string {
+	return questDistrib(o.AllQuestions, o.Title, testTitle, o.Points, numTest)
+}
+
+func (c *ClozeSt) DistribLatex(testTitle string, numTest uint) []string {
+	return questDistrib(c.AllQuestions, c.Title, testTitle, c.Points, numTest)
+}
+
+func (e *EssaySt) DistribLatex(testTitle string, numTest uint) []string {
+	return questDistrib(e.AllQuestions, e.Title, testTitle, e.Points, numTest)
+}
+
+func (d *DiagramSt) DistribLatex(testTitle string, numTest uint) []string {
+	return questDistrib(d.AllQuestions, d.Title, testTitle, d.Points, numTest)
+}
+
 func (w *WordProblemSt) DistribLatex(testTitle string, numTest uint) []string {
 	return questDistrib(w.AllQuestions, w.Title, testTitle, w.Points, numTest)
 }
@@ -29,7 +64,7 @@ func (w *WordMatchSt) DistribLatex(testTitle string, numTest uint) []string {
 }
 
 func (p *PassageCompletionSt) DistribLatex(testTitle string, numTest uint) []string {
-	return []string{}
+	return questDistrib(p.AllQuestions, p.Title, testTitle, p.Points, numTest)
 }
 
 func (c *CustomSt) DistribLatex(testTitle string, numTest uint) []string {
@@ -2,13 +2,20 @@ package testquestion
 
 import (
 	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"os"
 	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/test"
 	"fyne.io/fyne/v2/widget"
 	"github.com/abaskin/testparts"
@@ -17,6 +24,17 @@ import (
 	"github.com/hako/durafmt"
 )
 
+const (
+	// imageBaseWidth is how wide, in pixels, an image sized to the full
+	// line is shown.
+	imageBaseWidth   = 600
+	choiceImageWidth = 0.3
+)
+
+// MathRenderer, when set, shows questions and choices holding TeX math as
+// rendered images instead of their Unicode approximation.
+var MathRenderer *testparts.MathRendererSt
+
 type TestQuestion struct {
 	Question      *testparts.GormQuestion
 	Options       *LabelRadioGroup
@@ -25,6 +43,7 @@ type TestQuestion struct {
 	Resolution    time.Duration
 	CorrectAnswer *string
 	Answer        string
+	Answers       []string
 	Feedback      *string
 	allocTime     time.Duration
 	next          *widget.Button
@@ -90,28 +109,54 @@ func (q *TestQuestion) Ask(countDown *widget.ProgressBar, content *fyne.Containe
 }
 
 func (q *TestQuestion) Show() *fyne.Container {
-	showContainer := container.NewVBox(
+	showContainer := container.NewVBox()
+	if q.Question.Image != "" && q.Question.ImageAbove {
+		showContainer.Add(questionImage(q.Question.Image, q.Question.ImageWidth))
+	}
+	var question widget.RichTextSegment = &widget.TextSegment{
+		Text: q.Question.Question,
+		Style: widget.RichTextStyle{
+			TextStyle: fyne.TextStyle{
+				Bold: true,
+			},
+			Alignment: fyne.TextAlignCenter,
+			SizeName:  "QuestionFontSize",
+			ColorName: "QuestionColor",
+		},
+	}
+	if math := mathSegment(q.Question.QuestionTeX); math != nil {
+		question = math
+	}
+	segments := []widget.RichTextSegment{question}
+	if q.Question.SelectAll {
+		segments = append(segments, &widget.TextSegment{
+			Text: testparts.SelectAllNote,
+			Style: widget.RichTextStyle{
+				TextStyle: fyne.TextStyle{
+					Italic: true,
+				},
+				Alignment: fyne.TextAlignCenter,
+				ColorName: "QuestionColor",
+			},
+		})
+	}
+	showContainer.Add(
 		&widget.RichText{
 			Wrapping:   fyne.TextWrapWord,
 			Scroll:     container.ScrollNone,
 			Truncation: fyne.TextTruncateOff,
-			Segments: []widget.RichTextSegment{
-				&widget.TextSegment{
-					Text: q.Question.Question,
-					Style: widget.RichTextStyle{
-						TextStyle: fyne.TextStyle{
-							Bold: true,
-						},
-						Alignment: fyne.TextAlignCenter,
-						SizeName:  "QuestionFontSize",
-						ColorName: "QuestionColor",
-					},
-				},
-			},
+			Segments:   segments,
 		},
 	)
 
+	if q.Question.Image != "" && !q.Question.ImageAbove {
+		showContainer.Add(questionImage(q.Question.Image, q.Question.ImageWidth))
+	}
+
 	q.OptionList.Each(func(_ int, opt *ClickText) {
+		if opt.choice.Image != "" {
+			showContainer.Add(questionImage(opt.choice.Image, choiceImageWidth))
+		}
 		showContainer.Add(opt)
 	})
 
@@ -119,7 +164,15 @@ func (q *TestQuestion) Show() *fyne.Container {
 }
 
 func (q *TestQuestion) Correct() bool {
-	return q.Answer == *q.CorrectAnswer
+	if !q.Question.SelectAll {
+		return q.Answer == *q.CorrectAnswer
+	}
+	for _, c := range q.Question.Choices {
+		if c.Answer != slices.Contains(q.Answers, c.Choice) {
+			return false
+		}
+	}
+	return true
 }
 
 func (q *TestQuestion) AnswerID() uint {
@@ -131,6 +184,22 @@ func (q *TestQuestion) AnswerID() uint {
 	return 0
 }
 
+// Score returns the points the picked choices earn, less the question's
+// penalty when they are wrong.
+func (q *TestQuestion) Score() float64 {
+	chosen := make([]*testparts.GormQuestionChoice, 0)
+	for i, c := range q.Question.Choices {
+		picked := q.Answer == c.Choice
+		if q.Question.SelectAll {
+			picked = slices.Contains(q.Answers, c.Choice)
+		}
+		if picked {
+			chosen = append(chosen, &q.Question.Choices[i])
+		}
+	}
+	return q.Question.Score(chosen)
+}
+
 func (q *TestQuestion) SetFeedback() {
 	q.Feedback = &q.Question.Choices[slices.IndexFunc(q.Question.Choices,
 		func(c testparts.GormQuestionChoice) bool {
@@ -201,6 +270,47 @@ func (l *LabelRadioGroup) SetSelect(keyPressed fyne.KeyName) {
 	}
 }
 
+// questionImage loads an image from a file or URL, sized to a fraction of
+// the line.
+func questionImage(source string, width float64) *canvas.Image {
+	var img *canvas.Image
+	aspect := float32(4) / 3
+	if uri, err := storage.ParseURI(source); err == nil && strings.Contains(source, "://") {
+		img = canvas.NewImageFromURI(uri)
+	} else {
+		img = canvas.NewImageFromFile(source)
+		if file, err := os.Open(source); err == nil {
+			if config, _, err := image.DecodeConfig(file); err == nil && config.Height != 0 {
+				aspect = float32(config.Width) / float32(config.Height)
+			}
+			file.Close()
+		}
+	}
+
+	img.FillMode = canvas.ImageFillContain
+	imgWidth := float32(width * imageBaseWidth)
+	img.SetMinSize(fyne.NewSize(imgWidth, imgWidth/aspect))
+	return img
+}
+
+// mathSegment renders text with MathRenderer, returning nil when there is
+// no math or no renderer.
+func mathSegment(text string) *widget.ImageSegment {
+	if MathRenderer == nil || text == "" {
+		return nil
+	}
+	pngPath, err := MathRenderer.Render(text)
+	if err != nil {
+		log.Println("Unable to render math, error: ", err)
+		return nil
+	}
+	return &widget.ImageSegment{
+		Source:    storage.NewFileURI(pngPath),
+		Title:     text,
+		Alignment: fyne.TextAlignCenter,
+	}
+}
+
 func formatChoice(choice string) *widget.RichText {
 	return &widget.RichText{
 		Wrapping:   fyne.TextWrapWord,
@@ -235,13 +345,28 @@ func NewClickText(question *TestQuestion, choice testparts.GormQuestionChoice) *
 		question: question,
 		choice:   choice,
 	}
+	if math := mathSegment(choice.ChoiceTeX); math != nil {
+		ct.RichText.Segments[0].(*widget.TextSegment).Text =
+			fmt.Sprintf("%c.", 'A'+question.OptionList.Size())
+		ct.RichText.Segments = append(ct.RichText.Segments, math)
+	}
 	ct.ExtendBaseWidget(ct)
 	return ct
 }
 
+// Tapped picks the choice, or in a select all that apply question picks
+// or drops it.
 func (ct *ClickText) Tapped(p *fyne.PointEvent) {
-	ct.question.Answer = ct.choice.Choice
-	ct.question.SetFeedback()
+	q := ct.question
+	q.Answer = ct.choice.Choice
+	if q.Question.SelectAll {
+		if i := slices.Index(q.Answers, ct.choice.Choice); i >= 0 {
+			q.Answers = slices.Delete(q.Answers, i, i+1)
+		} else {
+			q.Answers = append(q.Answers, ct.choice.Choice)
+		}
+	}
+	q.SetFeedback()
 	ct.SetSelected()
 }
 
@@ -251,7 +376,8 @@ func (ct *ClickText) SetSelected() {
 			style := &c.RichText.Segments[0].(*widget.TextSegment).Style
 			style.ColorName = "OptionColor"
 			style.TextStyle.Italic = false
-			if c == ct {
+			if c == ct && !ct.question.Question.SelectAll ||
+				slices.Contains(ct.question.Answers, c.choice.Choice) {
 				style.ColorName = "OptionColorSelected"
 				style.TextStyle.Italic = true
 			}
@@ -0,0 +1,39 @@
+package testquestion
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/abaskin/testparts"
+)
+
+func TestTestQuestionScore(t *testing.T) {
+	test.NewApp()
+	question := func(selectAll bool) *testparts.GormQuestion {
+		return &testparts.GormQuestion{Points: 3, Penalty: 1, SelectAll: selectAll,
+			Choices: []testparts.GormQuestionChoice{
+				{Choice: "red", Answer: true}, {Choice: "blue", Answer: selectAll}, {Choice: "green"},
+			}}
+	}
+	tests := []struct {
+		name      string
+		selectAll bool
+		answer    string
+		answers   []string
+		want      float64
+	}{
+		{"right", false, "red", nil, 3},
+		{"wrong", false, "green", nil, -1},
+		{"blank", false, "", nil, 0},
+		{"all right", true, "", []string{"red", "blue"}, 3},
+		{"one missed", true, "", []string{"red"}, -1},
+		{"blank select all", true, "", nil, 0},
+	}
+	for _, tt := range tests {
+		q := NewTestQuestion(question(tt.selectAll), 0, nil)
+		q.Answer, q.Answers = tt.answer, tt.answers
+		if got := q.Score(); got != tt.want {
+			t.Errorf("%s: Score = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package testparts
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+)
+
+// "includeGift" files hold questions in Moodle's GIFT format, separated by
+// blank lines, each an optional ::title:: and [format], then its text with
+// the answers in braces:
+//
+//	multiple choice  {=right ~wrong#feedback ~%50%half right}
+//	true/false       {T} or {FALSE#feedback when wrong#feedback when right}
+//	short answer     {=answer =other accepted answer}
+//	numeric          {#3.14:0.01}, {#1..5} or {#=%100%2:0.1 =%50%2:1}
+//	matching         {=cat -> meow =dog -> woof}
+//	essay            {}
+//
+// Text after the answers makes a missing word question, the answers taking
+// the place of a blank. Each choice keeps its feedback. Items that do not
+// fit the section's type are left out with a warning: true/false items go
+// in true-false sections, numeric items in numeric ones and matching items
+// in word-match ones, whose pairs become words and definitions. Missing
+// word short answers make cloze questions. A multiple choice item without a
+// choice worth 100% is select all, its answers every choice earning credit.
+
+// readGift reads the items of a GIFT file.
+func readGift(filePath string) ([]bankItemSt, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]bankItemSt, 0)
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	text, start := []string{}, uint(0)
+	flush := func() error {
+		if len(text) == 0 {
+			return nil
+		}
+		item, err := parseGiftItem(strings.Join(text, "\n"))
+		if err != nil {
+			return ValidationErrorSt{File: filePath, Line: start, Column: 1, Message: err.Error()}
+		}
+		if item != nil {
+			item.line = start
+			items = append(items, *item)
+		}
+		text = text[:0]
+		return nil
+	}
+	for li, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "$CATEGORY:"):
+		case trimmed == "":
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		default:
+			if len(text) == 0 {
+				start = uint(li + 1)
+			}
+			text = append(text, line)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// parseGiftItem parses one item, returning nil for a description, which has
+// no answers.
+func parseGiftItem(text string) (*bankItemSt, error) {
+	item := &bankItemSt{}
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "::") {
+		end := giftIndex(text, "::", 2)
+		if end < 0 {
+			return nil, fmt.Errorf("the title is not closed with ::")
+		}
+		item.title = giftUnescape(text[2:end])
+		text = strings.TrimSpace(text[end+2:])
+	}
+	if strings.HasPrefix(text, "[") {
+		if end := strings.Index(text, "]"); end > 0 {
+			text = text[end+1:]
+		}
+	}
+
+	open := giftIndex(text, "{", 0)
+	if open < 0 {
+		return nil, nil
+	}
+	closing := giftIndex(text, "}", open)
+	if closing < 0 {
+		return nil, fmt.Errorf("the answers are not closed with }")
+	}
+	item.before = strings.TrimSpace(giftUnescape(text[:open]))
+	item.after = strings.TrimSpace(giftUnescape(text[closing+1:]))
+	answers := strings.TrimSpace(text[open+1 : closing])
+
+	var err error
+	switch {
+	case answers == "":
+		item.kind, item.question = bankEssay, &QuestionsSt{}
+	case giftIsTrueFalse(answers):
+		item.kind, item.question = bankTrueFalse, giftTrueFalseQuestion(answers)
+	case strings.HasPrefix(answers, "#"):
+		item.kind = bankNumeric
+		item.question, err = giftNumericQuestion(answers[1:])
+	default:
+		parsed := giftAnswers(answers)
+		switch {
+		case len(parsed) == 0:
+			return nil, fmt.Errorf("the answers %q start with neither = nor ~", answers)
+		case giftIndex(parsed[0].text, "->", 0) >= 0:
+			item.kind = bankMatching
+			item.pairs, err = giftPairs(parsed)
+			item.question = &QuestionsSt{}
+		case giftHasMark(parsed, '~'):
+			item.kind, item.question = bankChoice, bankChoiceQuestion(parsed, !slices.ContainsFunc(parsed,
+				func(answer bankAnswerSt) bool { return answer.weight >= 100 }))
+		default:
+			item.kind, item.question = bankShort, bankShortQuestion(parsed)
+		}
+	}
+	return item, err
+}
+
+// giftIndex finds sep in s from start, skipping escaped characters.
+func giftIndex(s, sep string, start int) int {
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case strings.HasPrefix(s[i:], sep):
+			return i
+		}
+	}
+	return -1
+}
+
+func giftUnescape(s string) string {
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				out.WriteByte('\n')
+				continue
+			}
+		}
+		out.WriteByte(s[i])
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// giftSplit splits s at every unescaped sep.
+func giftSplit(s, sep string) []string {
+	parts := make([]string, 0)
+	for {
+		i := giftIndex(s, sep, 0)
+		if i < 0 {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:i])
+		s = s[i+len(sep):]
+	}
+}
+
+func giftIsTrueFalse(answers string) bool {
+	switch strings.ToUpper(strings.TrimSpace(giftSplit(answers, "#")[0])) {
+	case "T", "TRUE", "F", "FALSE":
+		return true
+	}
+	return false
+}
+
+// giftTrueFalseQuestion keeps the feedback for a wrong and a right response
+// with the True and False choices true-false sections give the question.
+func giftTrueFalseQuestion(answers string) *QuestionsSt {
+	parts := giftSplit(answers, "#")
+	q := &QuestionsSt{True: strings.HasPrefix(strings.ToUpper(strings.TrimSpace(parts[0])), "T")}
+	wrong, right := "", ""
+	if len(parts) > 1 {
+		wrong = giftUnescape(parts[1])
+	}
+	if len(parts) > 2 {
+		right = giftUnescape(parts[2])
+	}
+	if wrong != "" || right != "" {
+		q.Feedback = WordsSt{List: arraylist.New(ternary(q.True, right, wrong), ternary(q.True, wrong, right))}
+	}
+	return q
+}
+
+// giftAnswers splits the answers in braces, each starting with = or ~.
+func giftAnswers(answers string) []bankAnswerSt {
+	marks := make([]int, 0)
+	for i := 0; i < len(answers); i++ {
+		switch answers[i] {
+		case '\\':
+			i++
+		case '=', '~':
+			marks = append(marks, i)
+		}
+	}
+
+	parsed := make([]bankAnswerSt, 0, len(marks))
+	for mi, at := range marks {
+		end := len(answers)
+		if mi+1 < len(marks) {
+			end = marks[mi+1]
+		}
+		answer := bankAnswerSt{mark: answers[at], weight: ternary(answers[at] == '=', 100.0, 0.0)}
+		text := strings.TrimSpace(answers[at+1 : end])
+		if strings.HasPrefix(text, "%") {
+			if close := strings.Index(text[1:], "%"); close >= 0 {
+				answer.weight, _ = strconv.ParseFloat(text[1:close+1], 64)
+				text = text[close+2:]
+			}
+		}
+		parts := giftSplit(text, "#")
+		answer.text = giftUnescape(parts[0])
+		for _, feedback := range parts[1:] {
+			answer.feedback = append(answer.feedback, giftUnescape(feedback))
+		}
+		parsed = append(parsed, answer)
+	}
+	return parsed
+}
+
+func giftHasMark(answers []bankAnswerSt, mark byte) bool {
+	for _, answer := range answers {
+		if answer.mark == mark {
+			return true
+		}
+	}
+	return false
+}
+
+// giftNumericQuestion reads a value with an optional tolerance, "3.14:0.01",
+// or a range, "1..5", taking the answer worth the most when there are
+// several.
+func giftNumericQuestion(answers string) (*QuestionsSt, error) {
+	spec := answers
+	if parsed := giftAnswers(answers); len(parsed) != 0 {
+		best := parsed[0]
+		for _, answer := range parsed[1:] {
+			if answer.weight > best.weight {
+				best = answer
+			}
+		}
+		spec = best.text
+	} else {
+		spec = giftUnescape(giftSplit(spec, "#")[0])
+	}
+
+	value, tolerance := 0.0, 0.0
+	var err error
+	switch {
+	case strings.Contains(spec, ".."):
+		bounds := strings.SplitN(spec, "..", 2)
+		low, lowErr := strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64)
+		high, highErr := strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64)
+		if lowErr != nil || highErr != nil {
+			return nil, fmt.Errorf("%q is not a numeric range", spec)
+		}
+		value, tolerance = (low+high)/2, (high-low)/2
+	case strings.Contains(spec, ":"):
+		parts := strings.SplitN(spec, ":", 2)
+		value, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err == nil {
+			tolerance, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		}
+	default:
+		value, err = strconv.ParseFloat(strings.TrimSpace(spec), 64)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a numeric answer", spec)
+	}
+	return &QuestionsSt{Value: &value, Tolerance: &ToleranceSt{Amount: tolerance}}, nil
+}
+
+func giftPairs(answers []bankAnswerSt) ([][2]string, error) {
+	pairs := make([][2]string, 0, len(answers))
+	for _, answer := range answers {
+		parts := strings.SplitN(answer.text, "->", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("matching answer %q has no ->", answer.text)
+		}
+		pairs = append(pairs, [2]string{strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])})
+	}
+	return pairs, nil
+}
+
+func ProcessIncludeGift(section JSONSectionSt, assetdir string) {
+	processBankInclude(section, assetdir, section.bankIncludes()[0])
+}
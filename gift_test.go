@@ -0,0 +1,111 @@
+package testparts
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGiftUnescape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`plain text`, "plain text"},
+		{`a\:b`, "a:b"},
+		{`\=x \~y \#z`, "=x ~y #z"},
+		{`\{braces\}`, "{braces}"},
+		{`one\ntwo`, "one\ntwo"},
+		{`back\\slash`, `back\slash`},
+		{`trailing\`, `trailing\`},
+	}
+	for _, tt := range tests {
+		if got := giftUnescape(tt.in); got != tt.want {
+			t.Errorf("giftUnescape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGiftEscapedAnswers(t *testing.T) {
+	tests := []struct {
+		text    string
+		choices []string
+		answer  uint
+	}{
+		{`Pick one {=a\~b ~c}`, []string{"a~b", "c"}, 1},
+		{`Pick one {~1\=2 =3\#4#right}`, []string{"1=2", "3#4"}, 2},
+		{`Braces \{x\} {~\{ =\}}`, []string{"{", "}"}, 2},
+	}
+	for _, tt := range tests {
+		item, err := parseGiftItem(tt.text)
+		if err != nil {
+			t.Errorf("parseGiftItem(%q): %v", tt.text, err)
+			continue
+		}
+		if item.kind != bankChoice {
+			t.Errorf("parseGiftItem(%q) kind = %s, want %s", tt.text, item.kind, bankChoice)
+			continue
+		}
+		if got := item.question.Choices.Values(); !slices.Equal(got, tt.choices) || item.question.Answer != tt.answer {
+			t.Errorf("parseGiftItem(%q) = %q answer %d, want %q answer %d",
+				tt.text, got, item.question.Answer, tt.choices, tt.answer)
+		}
+	}
+}
+
+func TestGiftMissingWord(t *testing.T) {
+	tests := []struct {
+		text, sectionType, want string
+	}{
+		{"The sun is {=hot ~cold} today.", "multiple-choice", "The sun is _____ today."},
+		{"Two plus two is {=four =4} exactly.", "cloze", "Two plus two is {{four}} exactly."},
+		{"Two plus two is {=four =4} exactly.", "quiz", "Two plus two is _____ exactly."},
+		{"Which is hot? {=sun ~ice}", "multiple-choice", "Which is hot?"},
+	}
+	for _, tt := range tests {
+		item, err := parseGiftItem(tt.text)
+		if err != nil {
+			t.Errorf("parseGiftItem(%q): %v", tt.text, err)
+			continue
+		}
+		if !item.fits(tt.sectionType) {
+			t.Errorf("%q does not fit a %s section", tt.text, tt.sectionType)
+			continue
+		}
+		if got := item.questionFor(tt.sectionType).Question.string; got != tt.want {
+			t.Errorf("%q in a %s section = %q, want %q", tt.text, tt.sectionType, got, tt.want)
+		}
+	}
+}
+
+func TestGiftNumeric(t *testing.T) {
+	tests := []struct {
+		text             string
+		value, tolerance float64
+		fails            bool
+	}{
+		{"Pi? {#3.14:0.01}", 3.14, 0.01, false},
+		{"Between? {#1..5}", 3, 2, false},
+		{"Below zero? {#-4..-2}", -3, 1, false},
+		{"Exactly? {#42}", 42, 0, false},
+		{"Best? {#=%50%2:1 =%100%2:0.1}", 2, 0.1, false},
+		{"Range? {#a..5}", 0, 0, true},
+		{"Tolerance? {#3:x}", 0, 0, true},
+	}
+	for _, tt := range tests {
+		item, err := parseGiftItem(tt.text)
+		switch {
+		case tt.fails:
+			if err == nil {
+				t.Errorf("parseGiftItem(%q) gave no error", tt.text)
+			}
+			continue
+		case err != nil:
+			t.Errorf("parseGiftItem(%q): %v", tt.text, err)
+			continue
+		}
+		q := item.question
+		if item.kind != bankNumeric || *q.Value != tt.value || q.Tolerance.Amount != tt.tolerance {
+			t.Errorf("parseGiftItem(%q) = %s %v±%v, want %v±%v",
+				tt.text, item.kind, *q.Value, q.Tolerance.Amount, tt.value, tt.tolerance)
+		}
+	}
+}
@@ -5,6 +5,7 @@ go 1.21
 require (
 	fyne.io/fyne/v2 v2.4.1
 	fyne.io/x/fyne v0.0.0-20231020065621-89b4a4aea27d
+	github.com/BurntSushi/toml v1.4.0
 	github.com/aldinokemal/go-aiken v0.0.0-20200804023432-8ed1c267e9cf
 	github.com/chonla/roman-number-go v0.0.0-20181101035413-6768129de021
 	github.com/daichi-m/go18ds v1.12.1
@@ -21,10 +22,12 @@ require (
 	github.com/pkg/errors v0.9.1
 	github.com/rwestlund/gotex v0.0.0-20170412080108-3c68d9bfff3b
 	github.com/samber/lo v1.39.0
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	github.com/thanhpk/randstr v1.0.6
 	github.com/wk8/go-ordered-map/v2 v2.1.8
 	golang.org/x/oauth2 v0.13.0
 	google.golang.org/api v0.149.0
+	gopkg.in/yaml.v3 v3.0.1
 	gorm.io/datatypes v1.2.0
 	gorm.io/driver/postgres v1.5.3
 	gorm.io/gorm v1.25.5
@@ -80,6 +83,5 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20231016165738-49dd2c1f3d0b // indirect
 	google.golang.org/grpc v1.59.0 // indirect
 	google.golang.org/protobuf v1.31.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	gorm.io/driver/mysql v1.4.7 // indirect
 )
@@ -213,20 +213,36 @@ func (gf *GoogleFormSt) AddSection(title, desc string) error {
 	return err
 }
 
-func (gf *GoogleFormSt) AddQuestions(questions QuestionSetSt,
-	qPoints int64) error {
+// AddQuestions adds questions to the form, each worth its points.
+func (gf *GoogleFormSt) AddQuestions(questions QuestionSetSt) error {
 	q, _ := questions.Get(0)
+	if q.Ordering {
+		return gf.addOrderingQuestions(questions)
+	}
 	if q.Choices.Size() != 0 {
-		return gf.addChoiceQuestions(questions, qPoints)
+		return gf.addChoiceQuestions(questions)
 	}
-	return gf.addTextQuestions(questions, qPoints)
+	return gf.addTextQuestions(questions)
 }
 
-func (gf *GoogleFormSt) addTextQuestions(questions QuestionSetSt,
-	qPoints int64) error {
+func (gf *GoogleFormSt) addTextQuestions(questions QuestionSetSt) error {
 	request := make([]*forms.Request, questions.Size())
 	questions.Each(
 		func(i int, q *QuestionsSt) {
+			textQuestion := &forms.TextQuestion{Paragraph: true}
+			grading := &forms.Grading{PointValue: int64(q.Points)}
+			switch {
+			case q.Numeric != nil:
+				textQuestion.Paragraph = false
+				grading.CorrectAnswers = &forms.CorrectAnswers{}
+				for _, answer := range q.Numeric.formsAnswers() {
+					grading.CorrectAnswers.Answers = append(grading.CorrectAnswers.Answers,
+						&forms.CorrectAnswer{Value: answer})
+				}
+			case q.Formula != "":
+				textQuestion.Paragraph = false
+				grading.CorrectAnswers = computedAnswers(q)
+			}
 			request[i] =
 				&forms.Request{
 					CreateItem: &forms.CreateItemRequest{
@@ -237,14 +253,11 @@ func (gf *GoogleFormSt) addTextQuestions(questions QuestionSetSt,
 						Item: &forms.Item{
 							Title: q.Question.CleanString(),
 							QuestionItem: &forms.QuestionItem{
+								Image: q.Image.formsImage(stemImageWidth),
 								Question: &forms.Question{
-									Required: true,
-									TextQuestion: &forms.TextQuestion{
-										Paragraph: true,
-									},
-									Grading: &forms.Grading{
-										PointValue: qPoints,
-									},
+									Required:     true,
+									TextQuestion: textQuestion,
+									Grading:      grading,
 								},
 							},
 						},
@@ -267,8 +280,36 @@ func (gf *GoogleFormSt) addTextQuestions(questions QuestionSetSt,
 	return err
 }
 
-func (gf *GoogleFormSt) addChoiceQuestions(questions QuestionSetSt,
-	qPoints int64) error {
+// computedAnswers accepts a computed answer as printed, without trailing
+// zeros, or with a leading dollar sign.
+func computedAnswers(q *QuestionsSt) *forms.CorrectAnswers {
+	answer, _ := q.Answers.Get(0)
+	accepted := []string{answer}
+	trimmed := strings.TrimSuffix(strings.TrimRight(answer, "0"), ".")
+	if strings.Contains(answer, ".") && trimmed != answer {
+		accepted = append(accepted, trimmed)
+	}
+
+	correct := &forms.CorrectAnswers{}
+	for _, value := range accepted {
+		correct.Answers = append(correct.Answers,
+			&forms.CorrectAnswer{Value: value}, &forms.CorrectAnswer{Value: "$" + value})
+	}
+	return correct
+}
+
+// choiceType is how Google Forms offers the question's choices.
+func (q *QuestionsSt) choiceType() string {
+	switch {
+	case q.selectAll():
+		return "CHECKBOX"
+	case q.Dropdown:
+		return "DROP_DOWN"
+	}
+	return "RADIO"
+}
+
+func (gf *GoogleFormSt) addChoiceQuestions(questions QuestionSetSt) error {
 	request := make([]*forms.Request, questions.Size())
 	questions.Each(
 		func(i int, q *QuestionsSt) {
@@ -277,11 +318,15 @@ func (gf *GoogleFormSt) addChoiceQuestions(questions QuestionSetSt,
 				func(i int, opt string) {
 					options[i] =
 						&forms.Option{
-							Value: opt,
+							Value: MathUnicode(opt),
+							Image: q.choiceImage(i).formsImage(choiceImageWidth),
 						}
 				},
 			)
-			answerStr, _ := q.Answers.Get(1)
+			correct := make([]*forms.CorrectAnswer, 0)
+			for _, answer := range q.correctChoices() {
+				correct = append(correct, &forms.CorrectAnswer{Value: MathUnicode(answer)})
+			}
 			request[i] = &forms.Request{
 				CreateItem: &forms.CreateItemRequest{
 					Location: &forms.Location{
@@ -291,27 +336,232 @@ func (gf *GoogleFormSt) addChoiceQuestions(questions QuestionSetSt,
 					Item: &forms.Item{
 						Title: q.Question.CleanString(),
 						QuestionItem: &forms.QuestionItem{
+							Image: q.Image.formsImage(stemImageWidth),
 							Question: &forms.Question{
 								Required: true,
 								ChoiceQuestion: &forms.ChoiceQuestion{
-									Shuffle: true,
-									Type:    "RADIO",
+									Shuffle: !q.KeepChoices,
+									Type:    q.choiceType(),
 									Options: options,
 								},
 								Grading: &forms.Grading{
-									PointValue: qPoints,
+									PointValue: int64(q.Points),
+									CorrectAnswers: &forms.CorrectAnswers{
+										Answers: correct,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			gf.nextItem++
+		},
+	)
+
+	response, err := gf.service.BatchUpdate(gf.form.FormId,
+		&forms.BatchUpdateFormRequest{
+			IncludeFormInResponse: true,
+			Requests:              request,
+		},
+	).Do()
+
+	if err == nil {
+		gf.form = response.Form
+	}
+	return err
+}
+
+// AddClozeQuestions adds each cloze passage with its blanks numbered,
+// followed by a short answer question for every blank worth its share of
+// the passage's points.
+func (gf *GoogleFormSt) AddClozeQuestions(questions QuestionSetSt) error {
+	request := make([]*forms.Request, 0)
+	qNum := &QuestNumSt{}
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			first := qNum.CurrentNumber() + 1
+			passage := NLStringSt{numberBlanks(q.Question.string, "(%d) ________", qNum)}
+			request = append(request, &forms.Request{
+				CreateItem: &forms.CreateItemRequest{
+					Location: &forms.Location{
+						Index:           gf.nextItem,
+						ForceSendFields: []string{"Index"},
+					},
+					Item: &forms.Item{
+						Title: ternary(q.Answers.Size() == 1, fmt.Sprintf("Blank (%d)", first),
+							fmt.Sprintf("Blanks (%d) to (%d)", first, qNum.CurrentNumber())),
+						Description: passage.CleanString(),
+						TextItem:    &forms.TextItem{},
+					},
+				},
+			})
+			gf.nextItem++
+
+			titles := make([]string, 0, q.Answers.Size())
+			for number := first; number <= qNum.CurrentNumber(); number++ {
+				titles = append(titles, fmt.Sprintf("(%d)", number))
+			}
+			request = append(request, gf.blankRequests(q, titles)...)
+		},
+	)
+
+	response, err := gf.service.BatchUpdate(gf.form.FormId,
+		&forms.BatchUpdateFormRequest{
+			IncludeFormInResponse: true,
+			Requests:              request,
+		},
+	).Do()
+
+	if err == nil {
+		gf.form = response.Form
+	}
+	return err
+}
+
+// AddDiagramQuestions adds each diagram with a short answer question for
+// every callout, which Forms cannot draw on the image so its title says
+// where the callout is.
+func (gf *GoogleFormSt) AddDiagramQuestions(questions QuestionSetSt) error {
+	request := make([]*forms.Request, 0)
+	number := 0
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			request = append(request, gf.introRequest(q.Question.CleanString(), q.Image))
+
+			titles := make([]string, 0, len(q.Callouts))
+			for _, callout := range q.Callouts {
+				number++
+				titles = append(titles, fmt.Sprintf("(%d) %s", number, callout.position()))
+			}
+			request = append(request, gf.blankRequests(q, titles)...)
+		},
+	)
+
+	response, err := gf.service.BatchUpdate(gf.form.FormId,
+		&forms.BatchUpdateFormRequest{
+			IncludeFormInResponse: true,
+			Requests:              request,
+		},
+	).Do()
+
+	if err == nil {
+		gf.form = response.Form
+	}
+	return err
+}
+
+// introRequest adds an item showing title and the image, or just the title
+// when Forms cannot show the image.
+func (gf *GoogleFormSt) introRequest(title string, img *ImageSt) *forms.Request {
+	item := &forms.Item{Title: title, TextItem: &forms.TextItem{}}
+	if image := img.formsImage(stemImageWidth); image != nil {
+		item = &forms.Item{Title: title, ImageItem: &forms.ImageItem{Image: image}}
+	}
+	request := &forms.Request{
+		CreateItem: &forms.CreateItemRequest{
+			Location: &forms.Location{
+				Index:           gf.nextItem,
+				ForceSendFields: []string{"Index"},
+			},
+			Item: item,
+		},
+	}
+	gf.nextItem++
+	return request
+}
+
+// AddImage adds an item showing the image, or its title when Forms cannot
+// show it.
+func (gf *GoogleFormSt) AddImage(title string, img *ImageSt) error {
+	if img == nil {
+		return nil
+	}
+	response, err := gf.service.BatchUpdate(gf.form.FormId,
+		&forms.BatchUpdateFormRequest{
+			IncludeFormInResponse: true,
+			Requests:              []*forms.Request{gf.introRequest(title, img)},
+		},
+	).Do()
+
+	if err == nil {
+		gf.form = response.Form
+	}
+	return err
+}
+
+// blankRequests adds a short answer question, titled from titles, for
+// each of the question's answers, worth its share of the question's points.
+func (gf *GoogleFormSt) blankRequests(q *QuestionsSt, titles []string) []*forms.Request {
+	request := make([]*forms.Request, 0, q.Answers.Size())
+	q.Answers.Each(
+		func(bi int, answer string) {
+			request = append(request, &forms.Request{
+				CreateItem: &forms.CreateItemRequest{
+					Location: &forms.Location{
+						Index:           gf.nextItem,
+						ForceSendFields: []string{"Index"},
+					},
+					Item: &forms.Item{
+						Title: titles[bi],
+						QuestionItem: &forms.QuestionItem{
+							Question: &forms.Question{
+								Required:     true,
+								TextQuestion: &forms.TextQuestion{},
+								Grading: &forms.Grading{
+									PointValue: int64(q.blankPoints(bi)),
 									CorrectAnswers: &forms.CorrectAnswers{
-										Answers: []*forms.CorrectAnswer{
-											{
-												Value: answerStr,
-											},
-										},
+										Answers: []*forms.CorrectAnswer{{Value: MathUnicode(answer)}},
 									},
 								},
 							},
 						},
 					},
 				},
+			})
+			gf.nextItem++
+		},
+	)
+	return request
+}
+
+// addOrderingQuestions adds each ordering question as a grid with a row for
+// every item and a column for every place. Forms does not mark grids.
+func (gf *GoogleFormSt) addOrderingQuestions(questions QuestionSetSt) error {
+	request := make([]*forms.Request, questions.Size())
+	questions.Each(
+		func(i int, q *QuestionsSt) {
+			places := make([]*forms.Option, q.Choices.Size())
+			rows := make([]*forms.Question, q.Choices.Size())
+			q.Choices.Each(
+				func(ci int, item string) {
+					places[ci] = &forms.Option{Value: fmt.Sprintf("%d", ci+1)}
+					rows[ci] = &forms.Question{
+						Required:    true,
+						RowQuestion: &forms.RowQuestion{Title: MathUnicode(item)},
+					}
+				},
+			)
+			request[i] = &forms.Request{
+				CreateItem: &forms.CreateItemRequest{
+					Location: &forms.Location{
+						Index:           gf.nextItem,
+						ForceSendFields: []string{"Index"},
+					},
+					Item: &forms.Item{
+						Title: q.Question.CleanString(),
+						QuestionGroupItem: &forms.QuestionGroupItem{
+							Image: q.Image.formsImage(stemImageWidth),
+							Grid: &forms.Grid{
+								Columns: &forms.ChoiceQuestion{
+									Type:    "RADIO",
+									Options: places,
+								},
+							},
+							Questions: rows,
+						},
+					},
+				},
 			}
 			gf.nextItem++
 		},
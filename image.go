@@ -0,0 +1,126 @@
+package testparts
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	rtfdoc "github.com/abaskin/testparts/rtf-doc"
+	"google.golang.org/api/forms/v1"
+)
+
+// An image can accompany a question stem or any of its choices. "file" is
+// relative to the asset directory and is used by the LaTeX, RTF and live
+// quiz output; Google Forms can only show images it can fetch, so it needs
+// "url". "width" is a fraction of the line, or of the choice's column, and
+// "placement" puts a stem image "above" or "below" (the default) the text.
+
+type ImageSt struct {
+	File      string  `json:"file"`
+	URL       string  `json:"url"`
+	Width     float64 `json:"width"`
+	Placement string  `json:"placement"`
+}
+
+const (
+	stemImageWidth   = 0.5
+	choiceImageWidth = 0.9
+
+	// formsImageWidth is the widest image, in pixels, a form item shows.
+	formsImageWidth = 740
+)
+
+// resolve makes the image's file absolute, as the test's logo is.
+func (img *ImageSt) resolve(assetDir string) {
+	if img != nil && img.File != "" && !filepath.IsAbs(img.File) {
+		img.File, _ = filepath.Abs(assetDir + "/" + img.File)
+	}
+}
+
+func (img *ImageSt) width(defaultWidth float64) float64 {
+	if img == nil || img.Width <= 0 {
+		return defaultWidth
+	}
+	return img.Width
+}
+
+func (img *ImageSt) above() bool {
+	return img != nil && img.Placement == "above"
+}
+
+// source is where the live quiz loads the image from.
+func (img *ImageSt) source() string {
+	if img == nil {
+		return ""
+	}
+	return ternary(img.File != "", img.File, img.URL)
+}
+
+func (img *ImageSt) latex(defaultWidth float64) string {
+	if img == nil || img.File == "" {
+		return ""
+	}
+	return fmt.Sprintf(`\includegraphics[width=%.2f\linewidth]{%s}`,
+		img.width(defaultWidth), filepath.ToSlash(img.File))
+}
+
+// addRTF adds the image to p, scaled to its share of maxWidth twips.
+func (img *ImageSt) addRTF(p *rtfdoc.Paragraph, maxWidth int, defaultWidth float64) {
+	if img == nil || img.File == "" {
+		return
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(img.File)), ".")
+	format = ternary(format == "jpg", rtfdoc.ImageFormatJpeg, format)
+	if format != rtfdoc.ImageFormatJpeg && format != rtfdoc.ImageFormatPng {
+		log.Printf("Warning: %s is not a PNG or JPEG image, left out of the RTF file\n", img.File)
+		return
+	}
+
+	source, err := os.ReadFile(img.File)
+	if err != nil {
+		log.Printf("Unable to read image %s, error: %v\n", img.File, err)
+		return
+	}
+	p.AddPicture(source, format).
+		SetWidthKeepRatio(int(img.width(defaultWidth) * float64(maxWidth) / 15))
+}
+
+func (img *ImageSt) formsImage(defaultWidth float64) *forms.Image {
+	if img == nil || img.URL == "" {
+		if img != nil {
+			log.Printf("Warning: image %s has no url, left out of the form\n", img.File)
+		}
+		return nil
+	}
+	return &forms.Image{
+		SourceUri: img.URL,
+		Properties: &forms.MediaProperties{
+			Alignment: "CENTER",
+			Width:     int64(img.width(defaultWidth) * formsImageWidth),
+		},
+	}
+}
+
+func (q *QuestionsSt) choiceImage(index int) *ImageSt {
+	if index < len(q.ChoiceImages) {
+		return q.ChoiceImages[index]
+	}
+	return nil
+}
+
+// choiceFeedback is what a student choosing the choice at index is told.
+func (q *QuestionsSt) choiceFeedback(index int) string {
+	if feedback, found := q.Feedback.fixMissing().Get(index); found {
+		return feedback
+	}
+	return ""
+}
+
+func (q *QuestionsSt) resolveImages(assetDir string) {
+	q.Image.resolve(assetDir)
+	for _, img := range q.ChoiceImages {
+		img.resolve(assetDir)
+	}
+}
@@ -8,6 +8,10 @@ import (
 	"github.com/nwillc/genfuncs"
 )
 
+// trueFalseGridWidth is how many questions fit across the true-false
+// answer grid.
+const trueFalseGridWidth = 10
+
 func testQR(test *TestBundleSt) (string, string) {
 	md5 := md5.Sum([]byte(test.Title))
 	return strings.Join([]string{
@@ -19,6 +23,8 @@ func testQR(test *TestBundleSt) (string, string) {
 func DocumentBegin(test *TestBundleSt) []string {
 	qrText, studentName := testQR(test)
 	return []string{
+		fmt.Sprintf(`%% seed %d, student %d`, test.Seed, test.StudentNum),
+		`\usepackage{graphicx}`,
 		`\begin{document}`,
 		`\testSetFooter`,
 		fmt.Sprintf(`{%s}{%s}{%s}`, test.Grade, test.Subject, test.School),
@@ -34,6 +40,7 @@ func DocumentEnd(test *TestBundleSt) []string {
 }
 
 func QuizSheet(quiz *TestBundleSt, sections []SectionSt, qNum *QuestNumSt) []string {
+	sections = sectionsFor(sections, OutputLatex)
 	outStr := []string{
 		`\begin{quiz}`,
 		fmt.Sprintf(`{%s}{%s}{%s}{%d}`, quiz.Student, quiz.Title, quiz.Date, quiz.Points),
@@ -48,6 +55,7 @@ func QuizSheet(quiz *TestBundleSt, sections []SectionSt, qNum *QuestNumSt) []str
 }
 
 func TestSheet(test *TestBundleSt, sections []SectionSt, qNum *QuestNumSt) []string {
+	sections = sectionsFor(sections, OutputLatex)
 	outStr := []string{
 		`\begin{test}`,
 		fmt.Sprintf(`{%s}{0.75}`, test.Logo),
@@ -71,6 +79,7 @@ func TestSheet(test *TestBundleSt, sections []SectionSt, qNum *QuestNumSt) []str
 
 func AnswerSheet(test *TestBundleSt, sections []SectionSt, isKey, showAll bool,
 	qNum *QuestNumSt) []string {
+	sections = sectionsFor(sections, OutputLatex)
 	outStr := make([]string, 0)
 
 	switch {
@@ -99,7 +108,7 @@ func AnswerSheet(test *TestBundleSt, sections []SectionSt, isKey, showAll bool,
 func QuestDistoSheet(test TestJSONSt, sections []SectionSt) []string {
 	outStr := []string{}
 
-	for _, s := range sections {
+	for _, s := range sectionsFor(sections, OutputDistrib) {
 		graph := s.DistribLatex(test.Title, uint(test.Students.Size()))
 		if len(graph) > 0 {
 			outStr = append(outStr, graph...)
@@ -116,7 +125,7 @@ func questionsLatex(questions QuestionSetSt, head SectionHeadSt, isQuiz bool, qN
 
 	questions.Each(
 		func(_ int, q *QuestionsSt) {
-			outStr = q.Begin(outStr)
+			outStr = q.Begin(outStr, head.QuestionPoints)
 
 			numCol := uint(4)
 			if head.NumCol != 0 {
@@ -143,14 +152,14 @@ func questionsLatex(questions QuestionSetSt, head SectionHeadSt, isQuiz bool, qN
 	return append(outStr, `\end{questions}`)
 }
 
-func answerTable(questions QuestionSetSt, showAnswers bool) []string {
+func answerTable(questions QuestionSetSt, showAnswers, showPoints bool) []string {
 	numCol := 1
 	questions.Each(
 		func(_ int, q *QuestionsSt) {
 			if q.Answers.Size() == 0 && showAnswers {
 				return
 			}
-			numCol = genfuncs.Min(numCol, q.Parts.Size())
+			numCol = genfuncs.Min(numCol, genfuncs.Max(q.Parts.Size(), q.Answers.Size()))
 		},
 	)
 
@@ -182,14 +191,59 @@ func answerTable(questions QuestionSetSt, showAnswers bool) []string {
 
 		if showAnswers {
 			copy(aLine, q.Answers.Values())
+			for ai := range aLine {
+				if points, found := q.partPoints(ai); found {
+					aLine[ai] = strings.TrimSpace(aLine[ai] + " " + pointsText(points))
+				}
+			}
 		}
-		aLine = append([]string{fmt.Sprintf("%d", qi+1)}, aLine...)
+		number := fmt.Sprintf("%d", qi+1)
+		if showPoints || len(q.PartPoints) != 0 {
+			number += " " + pointsText(q.Points)
+		}
+		aLine = append([]string{number}, aLine...)
 		outStr = append(outStr, strings.Join(aLine, " & ")+` \\ \hline`)
 	})
 
 	return append(outStr, `\end{tabularx}`)
 }
 
+// trueFalseGrid lays out the answers trueFalseGridWidth questions to a row,
+// with T and F to circle or, in the key, the answer.
+func trueFalseGrid(questions QuestionSetSt, start uint32, isKey bool) []string {
+	outStr := make([]string, 0)
+	values := questions.Values()
+	columns := genfuncs.Min(trueFalseGridWidth, len(values))
+	for first := 0; first < len(values); first += columns {
+		numbers := []string{`Question`}
+		answers := []string{`Answer`}
+		for i := first; i < first+columns; i++ {
+			switch {
+			case i >= len(values):
+				numbers, answers = append(numbers, " "), append(answers, " ")
+			case isKey:
+				numbers = append(numbers, fmt.Sprintf("%d", int(start)+i+1))
+				answers = append(answers, ternary(values[i].True, "T", "F"))
+			default:
+				numbers = append(numbers, fmt.Sprintf("%d", int(start)+i+1))
+				answers = append(answers, `T\quad F`)
+			}
+		}
+		outStr = append(outStr, []string{
+			`\noindent`,
+			fmt.Sprintf(
+				`\begin{tabularx}{\textwidth}{@{\rule[-5.25mm]{0pt}{12mm}}|Y|*{%d}{C|}}`,
+				columns),
+			`\hline`,
+			strings.Join(numbers, " & ") + ` \\ \hline`,
+			strings.Join(answers, " & ") + ` \\ \hline`,
+			`\end{tabularx}`,
+			`\vspace{0.25cm}`,
+		}...)
+	}
+	return outStr
+}
+
 func testSectionBegin(title string, points uint, inst string) []string {
 	return []string{
 		"",
@@ -205,12 +259,16 @@ func questionAnswerString(questions QuestionSetSt) string {
 	}), "")
 }
 
-func answerLines(questions QuestionSetSt, isKey bool, numLines string) []string {
+func answerLines(questions QuestionSetSt, isKey, showPoints bool, numLines string) []string {
 	if isKey {
 		return []string{
 			`\begin{enumerate}`,
 			`\large`,
 			strings.Join(stringsUsing(questions.Values(), func(value *QuestionsSt) string {
+				if showPoints || len(value.PartPoints) != 0 {
+					return fmt.Sprintf(`\item %s %s`, pointsText(value.Points),
+						strings.Join(value.Answers.Values(), "\n"))
+				}
 				return fmt.Sprintf(`\item %s`, strings.Join(value.Answers.Values(), "\n"))
 			}), "\n"),
 			`\normalsize`,
@@ -222,32 +280,95 @@ func answerLines(questions QuestionSetSt, isKey bool, numLines string) []string
 	return []string{fmt.Sprintf(`\answerLines{%d}{%s}`, questions.Size(), lines)}
 }
 
-func (q *QuestionsSt) Begin(outStr []string) []string {
-	return append(outStr, []string{
-		`\begin{minipage}{\linewidth}`,
-		`\question`,
-		q.Question.string,
-	}...)
+// blankAnswers numbers the answers of questions with several blanks, each
+// a question of its own, with a line to write on or, in the key, the answer.
+func blankAnswers(questions QuestionSetSt, isKey, showPoints bool, qNum *QuestNumSt) []string {
+	start := qNum.CurrentNumber()
+	blanks := make([]string, 0)
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			q.Answers.Each(
+				func(bi int, answer string) {
+					switch {
+					case !isKey:
+						blanks = append(blanks, `\item \rule{6cm}{0.4pt}`)
+					case showPoints:
+						blanks = append(blanks, fmt.Sprintf(`\item %s %s`, pointsText(q.blankPoints(bi)), answer))
+					default:
+						blanks = append(blanks, fmt.Sprintf(`\item %s`, answer))
+					}
+				},
+			)
+			qNum.AddNumber(uint32(q.Answers.Size()))
+		},
+	)
+	return []string{
+		`\begin{enumerate}`,
+		fmt.Sprintf(`\setcounter{enumi}{%d}`, start),
+		`\large`,
+		strings.Join(blanks, "\n"),
+		`\normalsize`,
+		`\end{enumerate}`,
+	}
+}
+
+func (q *QuestionsSt) Begin(outStr []string, showPoints bool) []string {
+	question := `\question`
+	if showPoints || len(q.PartPoints) != 0 {
+		question = fmt.Sprintf(`\question[%d]`, q.Points)
+	}
+	outStr = append(outStr, `\begin{minipage}{\linewidth}`, question)
+	if q.Image.above() {
+		outStr = append(outStr, q.imageLatex()...)
+	}
+	outStr = append(outStr, q.Question.string)
+	if q.selectAll() {
+		outStr = append(outStr, fmt.Sprintf(`\textit{%s}`, SelectAllNote))
+	}
+	if !q.Image.above() {
+		outStr = append(outStr, q.imageLatex()...)
+	}
+	return outStr
+}
+
+func (q *QuestionsSt) imageLatex() []string {
+	if q.Image == nil || q.Image.File == "" {
+		return nil
+	}
+	return []string{`\begin{center}`, q.Image.latex(stemImageWidth), `\end{center}`}
 }
 
 func (q *QuestionsSt) Choice(outStr []string, numCol uint) []string {
+	choices := make([]string, 0)
+	q.Choices.Each(
+		func(ci int, value string) {
+			if img := q.choiceImage(ci).latex(choiceImageWidth); img != "" {
+				value = strings.TrimSpace(img + " " + value)
+			}
+			choices = append(choices, fmt.Sprintf(`\choice %s`, value))
+		},
+	)
 	return append(outStr, []string{
 		fmt.Sprintf(`\begin{qchoices}(%d)`, numCol),
-		strings.Join(stringsUsing(q.Choices.Values(), func(value string) string {
-			return fmt.Sprintf(`\choice %s`, value)
-		}), "\n"),
+		strings.Join(choices, "\n"),
 		`\end{qchoices}`,
 	}...)
 }
 
 func (q *QuestionsSt) Part(outStr []string) []string {
+	parts := make([]string, 0)
+	q.Parts.Each(
+		func(pi int, value NLStringSt) {
+			if points, found := q.partPoints(pi); found {
+				parts = append(parts, fmt.Sprintf(`\part[%d] %s`, points, value.string))
+				return
+			}
+			parts = append(parts, fmt.Sprintf(`\part %s`, value.string))
+		},
+	)
 	return append(outStr, []string{
 		`\begin{parts}`,
-		strings.Join(stringsUsing(q.Parts.values(),
-			func(value string) string {
-				return fmt.Sprintf(`\part %s`, value)
-			},
-		), "\n"),
+		strings.Join(parts, "\n"),
 		`\end{parts}`,
 	}...)
 }
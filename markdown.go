@@ -0,0 +1,357 @@
+package testparts
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+)
+
+// "includeMarkdown" files hold a question pool in Markdown. A heading or a
+// numbered item starts each question with its stem, and the text under it
+// up to the next one belongs to it:
+//
+//	paragraphs       more of the question
+//	``` fence        a multi-line passage of the question, kept as written
+//	```part fence    one of the question's parts
+//	![](file.png)    the question's image
+//	- [x] / - [ ]    a right and a wrong choice, "> feedback" under it
+//	- answer         an accepted or model answer
+//	Points: 2        with Required, Difficulty, Tags, Value, Tolerance and
+//	                 Units, the question's fields
+//
+// True and False choices make a true/false item, a Value a numeric one and
+// {{blanks}} in the question a cloze one. WriteMarkdown writes any question
+// pool this way, leaving out what the format has no place for: formulas,
+// items to order, callouts and rubrics.
+
+var (
+	markdownHeadingRe = regexp.MustCompile(`^#{1,6}\s+(.*?)(?:\s+#+)?\s*$`)
+	markdownNumberRe  = regexp.MustCompile(`^\d+[.)]\s+(.*)$`)
+	markdownTaskRe    = regexp.MustCompile(`^[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	markdownBulletRe  = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	markdownQuoteRe   = regexp.MustCompile(`^>\s?(.*)$`)
+	markdownImageRe   = regexp.MustCompile(`^!\[[^\]]*\]\(([^)\s]+)(?:\s+"[^"]*")?\)$`)
+	markdownFieldRe   = regexp.MustCompile(`^(?i)(points|required|difficulty|tags|value|tolerance|units):\s*(.*)$`)
+)
+
+// markdownItemSt is a question being read.
+type markdownItemSt struct {
+	line       uint
+	paragraphs []string
+	newPara    bool
+	choices    []bankAnswerSt
+	answers    []string
+	parts      []string
+	question   *QuestionsSt
+}
+
+func (md *markdownItemSt) addText(text string) {
+	if md.newPara || len(md.paragraphs) == 0 {
+		md.paragraphs = append(md.paragraphs, text)
+	} else {
+		md.paragraphs[len(md.paragraphs)-1] += " " + text
+	}
+	md.newPara = false
+}
+
+// field sets the question field key to value.
+func (md *markdownItemSt) field(key, value string) error {
+	q := md.question
+	var err error
+	switch strings.ToLower(key) {
+	case "points":
+		var points uint64
+		points, err = strconv.ParseUint(value, 10, 0)
+		q.Points = uint(points)
+	case "required":
+		q.Required, err = bankBool(value)
+	case "difficulty":
+		q.Difficulty = value
+	case "tags":
+		q.Tags = WordsSt{List: arraylist.New(bankList(value)...)}
+	case "value":
+		var number float64
+		number, err = strconv.ParseFloat(value, 64)
+		q.Value = &number
+	case "tolerance":
+		q.Tolerance = &ToleranceSt{}
+		if q.Tolerance.Amount, err = strconv.ParseFloat(value, 64); err != nil {
+			err = q.Tolerance.UnmarshalJSON([]byte(strconv.Quote(value)))
+		}
+	case "units":
+		q.Units = value
+	}
+	if err != nil {
+		return fmt.Errorf("%s %q is not valid", key, value)
+	}
+	return nil
+}
+
+// item makes the question read an item of the kind its answers give.
+func (md *markdownItemSt) item() (bankItemSt, error) {
+	q := md.question
+	item := bankItemSt{line: md.line, before: strings.Join(md.paragraphs, "\n"), question: q}
+	q.Question = NLStringSt{item.before}
+	if len(md.parts) != 0 {
+		q.Parts = NLStringListSt{arraylist.New[NLStringSt]()}
+		for _, part := range md.parts {
+			q.Parts.Add(NLStringSt{part})
+		}
+	}
+
+	marked := 0
+	for _, choice := range md.choices {
+		marked += ternary(choice.weight > 0, 1, 0)
+	}
+	switch {
+	case len(md.choices) != 0 && marked == 0:
+		return item, fmt.Errorf("none of the choices is marked [x]")
+	case len(md.choices) == 2 && strings.EqualFold(md.choices[0].text, "true") &&
+		strings.EqualFold(md.choices[1].text, "false"):
+		tf := bankTrueFalseQuestion(md.choices)
+		item.kind, q.True, q.Feedback = bankTrueFalse, tf.True, tf.Feedback
+	case len(md.choices) != 0:
+		choice := bankChoiceQuestion(md.choices, marked > 1)
+		item.kind, q.Choices, q.Feedback, q.Answer, q.Correct = bankChoice,
+			choice.Choices, choice.Feedback, choice.Answer, choice.Correct
+	case q.Value != nil:
+		item.kind = bankNumeric
+	case strings.Contains(item.before, "{{"):
+		item.kind = bankCloze
+	case len(md.answers) != 0:
+		item.kind = bankShort
+	default:
+		item.kind = bankEssay
+	}
+	if len(md.answers) != 0 && item.kind != bankChoice {
+		q.Answers = WordsSt{List: arraylist.New(md.answers...)}
+	}
+	return item, nil
+}
+
+// markdownFence returns the fence a line opens, ``` or ~~~ or longer, and
+// its info string.
+func markdownFence(line string) (string, string, bool) {
+	for _, mark := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, mark) {
+			fence := line[:len(line)-len(strings.TrimLeft(line, mark[:1]))]
+			return fence, strings.TrimSpace(line[len(fence):]), true
+		}
+	}
+	return "", "", false
+}
+
+// readMarkdown reads the items of a Markdown question bank.
+func readMarkdown(filePath string) ([]bankItemSt, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]bankItemSt, 0)
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var md *markdownItemSt
+	errorAt := func(line uint, format string, a ...any) error {
+		return ValidationErrorSt{File: filePath, Line: line, Column: 1, Message: fmt.Sprintf(format, a...)}
+	}
+	flush := func() error {
+		if md == nil {
+			return nil
+		}
+		item, err := md.item()
+		if err != nil {
+			return errorAt(md.line, "%v", err)
+		}
+		items = append(items, item)
+		return nil
+	}
+
+	for li := 0; li < len(lines); li++ {
+		line := strings.TrimRight(lines[li], " \t")
+		trimmed := strings.TrimSpace(line)
+		stem := markdownHeadingRe.FindStringSubmatch(line)
+		if stem == nil {
+			stem = markdownNumberRe.FindStringSubmatch(line)
+		}
+
+		switch {
+		case stem != nil:
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			md = &markdownItemSt{line: uint(li + 1), question: &QuestionsSt{}}
+			md.addText(strings.TrimSpace(stem[1]))
+			md.newPara = strings.HasPrefix(line, "#")
+			continue
+		case trimmed == "":
+			if md != nil {
+				md.newPara = true
+			}
+			continue
+		case md == nil:
+			return nil, errorAt(uint(li+1),
+				"%q is not in a question, which starts with a heading or a numbered item", trimmed)
+		}
+
+		if fence, info, found := markdownFence(trimmed); found {
+			start, indent := li, len(line)-len(strings.TrimLeft(line, " "))
+			block := make([]string, 0)
+			for li++; li < len(lines); li++ {
+				if closing := strings.TrimSpace(lines[li]); strings.HasPrefix(closing, fence) &&
+					strings.Trim(closing, fence[:1]) == "" {
+					break
+				}
+				text := lines[li]
+				for strip := indent; strip > 0 && strings.HasPrefix(text, " "); strip-- {
+					text = text[1:]
+				}
+				block = append(block, text)
+			}
+			if li == len(lines) {
+				return nil, errorAt(uint(start+1), "the %s block is not closed", fence)
+			}
+			if info == "part" {
+				md.parts = append(md.parts, strings.Join(block, "\n"))
+			} else {
+				md.newPara = true
+				md.addText(strings.Join(block, "\n"))
+			}
+			md.newPara = true
+			continue
+		}
+
+		if task := markdownTaskRe.FindStringSubmatch(trimmed); task != nil {
+			md.choices = append(md.choices, bankAnswerSt{
+				text: strings.TrimSpace(task[2]), weight: ternary(task[1] == " ", 0.0, 100.0),
+			})
+		} else if quote := markdownQuoteRe.FindStringSubmatch(trimmed); quote != nil && len(md.choices) != 0 {
+			last := &md.choices[len(md.choices)-1]
+			last.feedback = append(last.feedback, strings.TrimSpace(quote[1]))
+		} else if bullet := markdownBulletRe.FindStringSubmatch(trimmed); bullet != nil {
+			md.answers = append(md.answers, strings.TrimSpace(bullet[1]))
+		} else if image := markdownImageRe.FindStringSubmatch(trimmed); image != nil {
+			md.question.Image = &ImageSt{File: image[1]}
+		} else if field := markdownFieldRe.FindStringSubmatch(trimmed); field != nil {
+			if err := md.field(field[1], strings.TrimSpace(field[2])); err != nil {
+				return nil, errorAt(uint(li+1), "%v", err)
+			}
+		} else {
+			md.addText(trimmed)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func ProcessIncludeMarkdown(section JSONSectionSt, assetdir string) {
+	processBankInclude(section, assetdir, section.bankIncludes()[3])
+}
+
+// markdownBlock fences lines, with a fence longer than any in them.
+func markdownBlock(info string, lines []string) []string {
+	fence := "```"
+	for _, line := range lines {
+		for strings.HasPrefix(strings.TrimSpace(line), fence) {
+			fence += "`"
+		}
+	}
+	return append(append([]string{fence + info}, lines...), fence)
+}
+
+// WriteMarkdown writes the questions as a Markdown question bank, those of
+// a true-false section with True and False choices.
+func (qs QuestionSetSt) WriteMarkdown(w io.Writer, sectionType string) error {
+	qs.fixMissing()
+	out := new(strings.Builder)
+	for qi, q := range qs.Values() {
+		marker := fmt.Sprintf("%d. ", qi+1)
+		indent := strings.Repeat(" ", len(marker))
+		block := func(lines ...string) {
+			out.WriteString("\n")
+			for _, line := range lines {
+				out.WriteString(strings.TrimRight(indent+line, " ") + "\n")
+			}
+		}
+
+		text := strings.Split(q.Question.string, "\n")
+		out.WriteString(marker + text[0] + "\n")
+		if len(text) > 1 {
+			block(markdownBlock("", text[1:])...)
+		}
+		if q.Image != nil && q.Image.File != "" {
+			block(fmt.Sprintf("![](%s)", q.Image.File))
+		}
+		q.Parts.fixMissing().Each(
+			func(_ int, part NLStringSt) {
+				block(markdownBlock("part", strings.Split(part.string, "\n"))...)
+			},
+		)
+
+		choices, right := q.Choices.fixMissing().Values(), map[uint]bool{q.Answer: true}
+		for _, correct := range q.Correct {
+			right[correct] = true
+		}
+		if sectionType == "true-false" && len(choices) == 0 {
+			choices, right = []string{"True", "False"}, map[uint]bool{ternary[uint](q.True, 1, 2): true}
+		}
+		lines := make([]string, 0)
+		feedback := q.Feedback.fixMissing().Values()
+		for ci, choice := range choices {
+			lines = append(lines, fmt.Sprintf("- [%s] %s", ternary(right[uint(ci+1)], "x", " "), choice))
+			if ci < len(feedback) && feedback[ci] != "" {
+				lines = append(lines, "  > "+feedback[ci])
+			}
+		}
+		value, units := q.Value, q.Units
+		if answer, _ := q.Answers.fixMissing().Get(0); sectionType == "numeric" && value == nil {
+			if number, answerUnits, err := parseNumericResponse(answer); err == nil {
+				value, units = &number, ternary(units != "", units, answerUnits)
+			}
+		}
+		if len(choices) == 0 && (value == nil || value == q.Value) {
+			for _, answer := range q.Answers.Values() {
+				lines = append(lines, "- "+answer)
+			}
+		}
+
+		if len(lines) != 0 {
+			block(lines...)
+		}
+
+		lines = lines[:0]
+		if q.Points != 0 {
+			lines = append(lines, fmt.Sprintf("Points: %d", q.Points))
+		}
+		if q.Required {
+			lines = append(lines, "Required: yes")
+		}
+		if q.Difficulty != "" {
+			lines = append(lines, "Difficulty: "+q.Difficulty)
+		}
+		if q.Tags.fixMissing().Size() != 0 {
+			lines = append(lines, "Tags: "+strings.Join(q.Tags.Values(), ", "))
+		}
+		if value != nil {
+			lines = append(lines, "Value: "+numberString(*value))
+		}
+		if q.Tolerance != nil {
+			lines = append(lines, "Tolerance: "+numberString(q.Tolerance.Amount)+ternary(q.Tolerance.Percent, "%", ""))
+		}
+		if units != "" {
+			lines = append(lines, "Units: "+units)
+		}
+		if len(lines) != 0 {
+			block(lines...)
+		}
+		out.WriteString("\n")
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
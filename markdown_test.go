@@ -0,0 +1,112 @@
+package testparts
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+)
+
+// markdownFields is what a question keeps through a Markdown round trip.
+type markdownFields struct {
+	Question   string
+	Parts      []string
+	Choices    []string
+	Feedback   []string
+	Answer     uint
+	Correct    []uint
+	Answers    []string
+	True       bool
+	Value      float64
+	Points     uint
+	Required   bool
+	Difficulty string
+	Tags       []string
+}
+
+func markdownFieldsOf(q *QuestionsSt) markdownFields {
+	fields := markdownFields{
+		Question: q.Question.string, Choices: q.Choices.fixMissing().Values(),
+		Answer: q.Answer, Correct: q.Correct, Answers: q.Answers.fixMissing().Values(),
+		True: q.True, Points: q.Points, Required: q.Required, Difficulty: q.Difficulty,
+		Tags: q.Tags.fixMissing().Values(),
+	}
+	q.Parts.fixMissing().Each(
+		func(_ int, part NLStringSt) {
+			fields.Parts = append(fields.Parts, part.string)
+		},
+	)
+	if feedback := q.Feedback.fixMissing().Values(); strings.Join(feedback, "") != "" {
+		fields.Feedback = feedback
+	}
+	if q.Value != nil {
+		fields.Value = *q.Value
+	}
+	return fields
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	value := 9.81
+	tests := []struct {
+		name        string
+		sectionType string
+		question    *QuestionsSt
+	}{
+		{"choice", "multiple-choice", &QuestionsSt{
+			Question: NLStringSt{"Which is a prime?"},
+			Choices:  WordsSt{List: arraylist.New("4", "7", "9")},
+			Feedback: WordsSt{List: arraylist.New("Even", "", "Three threes")},
+			Answer:   2, Points: 2, Difficulty: "easy",
+			Tags: WordsSt{List: arraylist.New("primes", "numbers")},
+		}},
+		{"select all", "multiple-choice", &QuestionsSt{
+			Question: NLStringSt{"Which are even?"},
+			Choices:  WordsSt{List: arraylist.New("2", "3", "4")},
+			Correct:  []uint{1, 3}, Required: true,
+		}},
+		{"true-false", "true-false", &QuestionsSt{
+			Question: NLStringSt{"The sun is a star."}, True: true,
+		}},
+		{"numeric", "numeric", &QuestionsSt{
+			Question: NLStringSt{"Gravity?"}, Value: &value,
+		}},
+		{"passage and parts", "essay", &QuestionsSt{
+			Question: NLStringSt{"Read the poem.\nRoses are red\n```\nnot a fence"},
+			Parts:    NLStringListSt{arraylist.New(NLStringSt{"Who wrote it?"}, NLStringSt{"Why?\nExplain."})},
+			Answers:  WordsSt{List: arraylist.New("A model answer")},
+		}},
+		{"cloze", "cloze", &QuestionsSt{
+			Question: NLStringSt{"Water boils at {{100}} degrees."},
+		}},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), "bank.md")
+		out := new(strings.Builder)
+		if err := (QuestionSetSt{List: arraylist.New(tt.question)}).WriteMarkdown(out, tt.sectionType); err != nil {
+			t.Fatalf("%s: WriteMarkdown: %v", tt.name, err)
+		}
+		if err := os.WriteFile(file, []byte(out.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		items, err := readMarkdown(file)
+		switch {
+		case err != nil:
+			t.Errorf("%s: readMarkdown: %v\n%s", tt.name, err, out)
+			continue
+		case len(items) != 1:
+			t.Errorf("%s: read %d items, want 1\n%s", tt.name, len(items), out)
+			continue
+		case !items[0].fits(tt.sectionType):
+			t.Errorf("%s: the %s item read does not fit a %s section", tt.name, items[0].kind, tt.sectionType)
+			continue
+		}
+		got, want := markdownFieldsOf(items[0].questionFor(tt.sectionType)), markdownFieldsOf(tt.question)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: read back\n%+v\nwant\n%+v\nfrom\n%s", tt.name, got, want, out)
+		}
+	}
+}
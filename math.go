@@ -0,0 +1,271 @@
+package testparts
+
+import (
+	"crypto/md5"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Question text may hold TeX math between $...$ or \(...\). LaTeX output
+// passes it through; RTF, Google Forms and the live quiz show a Unicode
+// approximation, or, when a MathRendererSt is given, images rendered by
+// latex and dvipng.
+
+var mathRe = regexp.MustCompile(`(^|[^\\])(\$([^$]+)\$|\\\((.+?)\\\))`)
+
+// replaceMath replaces each math span in text with convert's result.
+func replaceMath(text string, convert func(tex string) string) string {
+	return mathRe.ReplaceAllStringFunc(text,
+		func(span string) string {
+			match := mathRe.FindStringSubmatch(span)
+			return match[1] + convert(match[3]+match[4])
+		},
+	)
+}
+
+func hasMath(text string) bool {
+	return mathRe.MatchString(text)
+}
+
+// MathUnicode replaces the math in text with its Unicode approximation and
+// unescapes dollar signs.
+func MathUnicode(text string) string {
+	return strings.ReplaceAll(replaceMath(text,
+		func(tex string) string {
+			p := &texMathSt{tex: tex}
+			return p.convert(len(tex))
+		},
+	), `\$`, "$")
+}
+
+var (
+	texSymbols = map[string]string{
+		"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε",
+		"zeta": "ζ", "eta": "η", "theta": "θ", "iota": "ι", "kappa": "κ",
+		"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "rho": "ρ",
+		"sigma": "σ", "tau": "τ", "upsilon": "υ", "phi": "φ", "chi": "χ",
+		"psi": "ψ", "omega": "ω", "Gamma": "Γ", "Delta": "Δ", "Theta": "Θ",
+		"Lambda": "Λ", "Xi": "Ξ", "Pi": "Π", "Sigma": "Σ", "Phi": "Φ",
+		"Psi": "Ψ", "Omega": "Ω",
+		"times": "×", "div": "÷", "pm": "±", "mp": "∓", "cdot": "·",
+		"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "ne": "≠", "neq": "≠",
+		"approx": "≈", "equiv": "≡", "sim": "∼", "propto": "∝",
+		"infty": "∞", "circ": "°", "degree": "°", "angle": "∠",
+		"perp": "⊥", "parallel": "∥", "triangle": "△",
+		"rightarrow": "→", "to": "→", "leftarrow": "←", "Rightarrow": "⇒",
+		"leftrightarrow": "↔", "Leftrightarrow": "⇔",
+		"in": "∈", "notin": "∉", "subset": "⊂", "subseteq": "⊆", "cup": "∪",
+		"cap": "∩", "emptyset": "∅", "forall": "∀", "exists": "∃",
+		"sum": "∑", "prod": "∏", "int": "∫", "partial": "∂", "nabla": "∇",
+		"ldots": "…", "cdots": "⋯", "dots": "…", "%": "%", "$": "$", "{": "{",
+		"}": "}", "&": "&", "#": "#", "_": "_",
+		",": " ", ";": " ", ":": " ", " ": " ", "!": "", "quad": " ", "qquad": "  ",
+		"left": "", "right": "", "displaystyle": "",
+	}
+
+	superscripts = map[rune]rune{
+		'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶',
+		'7': '⁷', '8': '⁸', '9': '⁹', '+': '⁺', '-': '⁻', '=': '⁼', '(': '⁽',
+		')': '⁾', 'n': 'ⁿ', 'i': 'ⁱ', 'x': 'ˣ', 'y': 'ʸ', '°': '°',
+		'−': '⁻',
+	}
+
+	subscripts = map[rune]rune{
+		'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆',
+		'7': '₇', '8': '₈', '9': '₉', '+': '₊', '-': '₋', '=': '₌', '(': '₍',
+		')': '₎', 'a': 'ₐ', 'e': 'ₑ', 'o': 'ₒ', 'x': 'ₓ', 'i': 'ᵢ', 'n': 'ₙ',
+		'−': '₋',
+	}
+
+	vulgarFractions = map[string]string{
+		"1/2": "½", "1/3": "⅓", "2/3": "⅔", "1/4": "¼", "3/4": "¾", "1/5": "⅕",
+		"1/6": "⅙", "1/8": "⅛", "3/8": "⅜", "5/8": "⅝", "7/8": "⅞",
+	}
+)
+
+// texMathSt converts the TeX math in tex, a little at a time, to Unicode.
+type texMathSt struct {
+	tex string
+	pos int
+}
+
+// convert converts up to end, or up to the end of the current group.
+func (p *texMathSt) convert(end int) string {
+	out := strings.Builder{}
+	for p.pos < end && p.pos < len(p.tex) {
+		if p.tex[p.pos] == '}' {
+			p.pos++
+			break
+		}
+		out.WriteString(p.atom())
+	}
+	return out.String()
+}
+
+// atom converts the next character, command or group.
+func (p *texMathSt) atom() string {
+	ch, size := utf8.DecodeRuneInString(p.tex[p.pos:])
+	p.pos += size
+
+	switch ch {
+	case '{':
+		return p.convert(len(p.tex))
+	case '^':
+		return scriptString(p.argument(), superscripts, "^")
+	case '_':
+		return scriptString(p.argument(), subscripts, "_")
+	case '-':
+		return "−"
+	case '\\':
+		return p.command()
+	}
+	return string(ch)
+}
+
+func (p *texMathSt) command() string {
+	start := p.pos
+	for p.pos < len(p.tex) && (p.tex[p.pos] >= 'a' && p.tex[p.pos] <= 'z' ||
+		p.tex[p.pos] >= 'A' && p.tex[p.pos] <= 'Z') {
+		p.pos++
+	}
+	if p.pos == start && p.pos < len(p.tex) {
+		p.pos++
+	}
+	name := p.tex[start:p.pos]
+
+	switch name {
+	case "frac", "dfrac", "tfrac":
+		num, den := p.argument(), p.argument()
+		if vulgar, found := vulgarFractions[num+"/"+den]; found {
+			return vulgar
+		}
+		return mathGroup(num) + "/" + mathGroup(den)
+	case "sqrt":
+		return "√" + mathGroup(p.argument())
+	case "text", "mathrm", "mathbf", "mathit", "textbf", "textit", "operatorname", "mbox":
+		return p.argument()
+	case "overline", "bar":
+		return combine(p.argument(), '̅')
+	case "vec":
+		return combine(p.argument(), '⃗')
+	case "hat":
+		return combine(p.argument(), '̂')
+	}
+
+	if symbol, found := texSymbols[name]; found {
+		return symbol
+	}
+	return name
+}
+
+// argument converts the next atom, skipping spaces before it.
+func (p *texMathSt) argument() string {
+	for p.pos < len(p.tex) && p.tex[p.pos] == ' ' {
+		p.pos++
+	}
+	if p.pos >= len(p.tex) {
+		return ""
+	}
+	return p.atom()
+}
+
+// scriptString raises or lowers s with the Unicode script characters, or
+// writes it after mark when one of its characters has none.
+func scriptString(s string, script map[rune]rune, mark string) string {
+	out := strings.Builder{}
+	for _, ch := range s {
+		scripted, found := script[ch]
+		if !found {
+			return mark + mathGroup(s)
+		}
+		out.WriteRune(scripted)
+	}
+	return out.String()
+}
+
+func mathGroup(s string) string {
+	if utf8.RuneCountInString(s) <= 1 || strings.IndexAny(s, " +−-×/·=") < 0 {
+		return s
+	}
+	return "(" + s + ")"
+}
+
+func combine(s string, mark rune) string {
+	out := strings.Builder{}
+	for _, ch := range s {
+		out.WriteRune(ch)
+		out.WriteRune(mark)
+	}
+	return out.String()
+}
+
+// ---- Images ----
+
+// MathRendererSt renders text holding TeX math to PNG images with latex and
+// dvipng, keeping them in Workdir so each is only rendered once.
+type MathRendererSt struct {
+	Workdir string
+	Latex   string
+	Dvipng  string
+	DPI     uint
+	lock    sync.Mutex
+}
+
+func NewMathRenderer(workdir string) *MathRendererSt {
+	return &MathRendererSt{
+		Workdir: workdir,
+		Latex:   "/Library/TeX/texbin/latex",
+		Dvipng:  "/Library/TeX/texbin/dvipng",
+		DPI:     150,
+	}
+}
+
+// Render returns the path of a PNG image of text, which is typeset as a
+// LaTeX paragraph so math and the words around it match.
+func (mr *MathRendererSt) Render(text string) (string, error) {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+
+	name := fmt.Sprintf("math-%x", md5.Sum([]byte(text)))
+	pngPath := filepath.Join(mr.Workdir, name+".png")
+	if _, err := os.Stat(pngPath); err == nil {
+		return pngPath, nil
+	}
+
+	texPath := filepath.Join(mr.Workdir, name+".tex")
+	source := strings.Join([]string{
+		`\documentclass[preview,border=1pt]{standalone}`,
+		`\usepackage{amsmath}`,
+		`\begin{document}`,
+		text,
+		`\end{document}`,
+	}, "\n")
+	if err := os.WriteFile(texPath, []byte(source), 0644); err != nil {
+		return "", err
+	}
+	defer func() {
+		for _, ext := range []string{".tex", ".dvi", ".aux", ".log"} {
+			os.Remove(filepath.Join(mr.Workdir, name+ext))
+		}
+	}()
+
+	latex := exec.Command(mr.Latex, "-interaction=nonstopmode", name+".tex")
+	latex.Dir = mr.Workdir
+	if output, err := latex.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("latex failed on %q: %v\n%s", text, err, output)
+	}
+
+	dvipng := exec.Command(mr.Dvipng, "-T", "tight", "-bg", "Transparent",
+		"-D", fmt.Sprint(mr.DPI), "-o", name+".png", name+".dvi")
+	dvipng.Dir = mr.Workdir
+	if output, err := dvipng.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("dvipng failed on %q: %v\n%s", text, err, output)
+	}
+	return pngPath, nil
+}
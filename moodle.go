@@ -0,0 +1,452 @@
+package testparts
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+	"github.com/nwillc/genfuncs"
+)
+
+// "includeMoodle" files are Moodle XML question banks. Multiple choice,
+// true/false, short answer, numerical, essay, matching and cloze (embedded
+// answers) questions are read as items fitting sections like GIFT ones, a
+// cloze question only fitting cloze sections with each blank showing its
+// best answer. An essay's information for graders becomes its answer. Text
+// is read without its HTML; descriptions, points and embedded files are
+// left out, as are questions of other types with a warning. ConvertMoodle
+// turns a bank into include files instead.
+//
+// ExportMoodle writes the question pools of a test's sections as a bank,
+// each section in its own category, keeping choices, right answers, points
+// and feedback. A wrong choice costs the section's negative marking, and
+// for select all questions the whole question.
+
+type moodleQuizSt struct {
+	XMLName   xml.Name           `xml:"quiz"`
+	Questions []moodleQuestionSt `xml:"question"`
+}
+
+type moodleTextSt struct {
+	Format string `xml:"format,attr,omitempty"`
+	Text   string `xml:"text"`
+}
+
+type moodleAnswerSt struct {
+	Fraction  string        `xml:"fraction,attr"`
+	Format    string        `xml:"format,attr,omitempty"`
+	Text      string        `xml:"text"`
+	Feedback  *moodleTextSt `xml:"feedback,omitempty"`
+	Tolerance string        `xml:"tolerance,omitempty"`
+}
+
+type moodleSubquestionSt struct {
+	Format string       `xml:"format,attr,omitempty"`
+	Text   string       `xml:"text"`
+	Answer moodleTextSt `xml:"answer"`
+}
+
+type moodleUnitsSt struct {
+	Units []moodleUnitSt `xml:"unit"`
+}
+
+type moodleUnitSt struct {
+	Multiplier string `xml:"multiplier"`
+	Name       string `xml:"unit_name"`
+}
+
+type moodleQuestionSt struct {
+	Type           string                `xml:"type,attr"`
+	Category       *moodleTextSt         `xml:"category,omitempty"`
+	Name           *moodleTextSt         `xml:"name,omitempty"`
+	QuestionText   *moodleTextSt         `xml:"questiontext,omitempty"`
+	DefaultGrade   string                `xml:"defaultgrade,omitempty"`
+	Single         string                `xml:"single,omitempty"`
+	ShuffleAnswers string                `xml:"shuffleanswers,omitempty"`
+	Answers        []moodleAnswerSt      `xml:"answer"`
+	Subquestions   []moodleSubquestionSt `xml:"subquestion"`
+	Units          *moodleUnitsSt        `xml:"units,omitempty"`
+	ResponseFormat string                `xml:"responseformat,omitempty"`
+	GraderInfo     *moodleTextSt         `xml:"graderinfo,omitempty"`
+}
+
+var (
+	moodleBreakRe = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>`)
+	moodleTagRe   = regexp.MustCompile(`<[^>]*>`)
+	moodleClozeRe = regexp.MustCompile(`\{\d*:\w+:((?:\\.|[^\\}])*)\}`)
+	moodleEscaper = strings.NewReplacer(`\`, `\\`, `}`, `\}`, `#`, `\#`, `~`, `\~`, `/`, `\/`, `"`, `\"`)
+)
+
+// readMoodle reads the items of a Moodle XML file.
+func readMoodle(filePath string) ([]bankItemSt, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	items := make([]bankItemSt, 0)
+	category := ""
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return items, nil
+		}
+		line, column := decoder.InputPos()
+		if err != nil {
+			return nil, ValidationErrorSt{File: filePath, Line: uint(line), Column: uint(column), Message: err.Error()}
+		}
+		start, isStart := token.(xml.StartElement)
+		if !isStart || start.Name.Local != "question" {
+			continue
+		}
+
+		mq := moodleQuestionSt{}
+		if err := decoder.DecodeElement(&mq, &start); err != nil {
+			line, column := decoder.InputPos()
+			return nil, ValidationErrorSt{File: filePath, Line: uint(line), Column: uint(column), Message: err.Error()}
+		}
+		switch mq.Type {
+		case "category":
+			category = moodleCategory(mq.Category)
+		case "description":
+		default:
+			item, err := mq.item()
+			if err != nil {
+				return nil, ValidationErrorSt{File: filePath, Line: uint(line), Column: uint(column),
+					Message: fmt.Sprintf("%s question %q: %v", mq.Type, item.name(), err)}
+			}
+			item.line, item.category = uint(line), category
+			items = append(items, *item)
+		}
+	}
+}
+
+// moodleCategory is a category's path as one name, without its context.
+func moodleCategory(category *moodleTextSt) string {
+	path := strings.Split(moodleText(category), "/")
+	for len(path) > 1 && (strings.HasPrefix(path[0], "$") || path[0] == "top") {
+		path = path[1:]
+	}
+	return strings.Join(path, " ")
+}
+
+// item makes the question an item, one without a question when the package
+// cannot ask it.
+func (mq moodleQuestionSt) item() (*bankItemSt, error) {
+	item := &bankItemSt{kind: mq.Type, title: moodleText(mq.Name), before: moodleText(mq.QuestionText)}
+	answers := mq.bankAnswers()
+	var err error
+	switch mq.Type {
+	case "multichoice":
+		if len(answers) == 0 {
+			return item, fmt.Errorf("no answers")
+		}
+		item.kind, item.question = bankChoice, bankChoiceQuestion(answers, mq.Single == "false")
+	case "truefalse":
+		item.kind, item.question = bankTrueFalse, bankTrueFalseQuestion(answers)
+	case "shortanswer":
+		item.kind, item.question = bankShort, bankShortQuestion(answers)
+	case "numerical":
+		item.kind = bankNumeric
+		item.question, err = mq.numericQuestion()
+	case "essay":
+		item.kind, item.question = bankEssay, &QuestionsSt{}
+		if info := moodleText(mq.GraderInfo); info != "" {
+			item.question.Answers = WordsSt{List: arraylist.New(info)}
+		}
+	case "matching":
+		item.kind, item.question = bankMatching, &QuestionsSt{}
+		for _, sub := range mq.Subquestions {
+			// Answers without a subquestion only mislead.
+			if text := moodleText(&moodleTextSt{sub.Format, sub.Text}); text != "" {
+				item.pairs = append(item.pairs, [2]string{text, moodleText(&sub.Answer)})
+			}
+		}
+	case "cloze", "multianswer":
+		item.kind = bankCloze
+		item.before, err = moodleClozeText(mq.QuestionText)
+		item.question = &QuestionsSt{Question: NLStringSt{item.before}}
+	}
+	return item, err
+}
+
+// moodleText is the text, without its HTML if it has any.
+func moodleText(text *moodleTextSt) string {
+	if text == nil {
+		return ""
+	}
+	if text.Format != "" && text.Format != "html" {
+		return strings.TrimSpace(text.Text)
+	}
+	plain := moodleBreakRe.ReplaceAllString(text.Text, "\n")
+	plain = html.UnescapeString(moodleTagRe.ReplaceAllString(plain, ""))
+	return bankLines(plain)
+}
+
+func (mq moodleQuestionSt) bankAnswers() []bankAnswerSt {
+	answers := make([]bankAnswerSt, 0, len(mq.Answers))
+	for _, ma := range mq.Answers {
+		weight, _ := strconv.ParseFloat(ma.Fraction, 64)
+		answer := bankAnswerSt{
+			mark:   ternary(weight > 0, byte('='), byte('~')),
+			weight: weight,
+			text:   moodleText(&moodleTextSt{ma.Format, ma.Text}),
+		}
+		if feedback := moodleText(ma.Feedback); feedback != "" {
+			answer.feedback = []string{feedback}
+		}
+		answers = append(answers, answer)
+	}
+	return answers
+}
+
+// numericQuestion takes the answer worth the most, in the units that need
+// no multiplier.
+func (mq moodleQuestionSt) numericQuestion() (*QuestionsSt, error) {
+	if len(mq.Answers) == 0 {
+		return nil, fmt.Errorf("no answers")
+	}
+	best, bestWeight := mq.Answers[0], -1.0
+	for _, answer := range mq.Answers {
+		if weight, _ := strconv.ParseFloat(answer.Fraction, 64); weight > bestWeight {
+			best, bestWeight = answer, weight
+		}
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(best.Text), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a numeric answer", best.Text)
+	}
+	tolerance, _ := strconv.ParseFloat(strings.TrimSpace(best.Tolerance), 64)
+	q := &QuestionsSt{Value: &value, Tolerance: &ToleranceSt{Amount: tolerance}}
+	if mq.Units == nil {
+		return q, nil
+	}
+	for _, unit := range mq.Units.Units {
+		if multiplier, _ := strconv.ParseFloat(unit.Multiplier, 64); multiplier == 1 {
+			q.Units = strings.TrimSpace(unit.Name)
+			break
+		}
+	}
+	return q, nil
+}
+
+// moodleClozeText replaces each embedded answer of a cloze question with
+// the best of its answers in a cloze blank.
+func moodleClozeText(text *moodleTextSt) (string, error) {
+	var err error
+	blanks := moodleClozeRe.ReplaceAllStringFunc(text.Text,
+		func(embedded string) string {
+			spec := moodleClozeRe.FindStringSubmatch(embedded)[1]
+			if !strings.HasPrefix(spec, "=") && !strings.HasPrefix(spec, "~") {
+				spec = "~" + spec
+			}
+			answers := giftAnswers(spec)
+			if len(answers) == 0 {
+				err = fmt.Errorf("embedded answer %s has no answers", embedded)
+				return embedded
+			}
+			best := answers[0]
+			for _, answer := range answers[1:] {
+				if answer.weight > best.weight {
+					best = answer
+				}
+			}
+			// A numerical answer's tolerance follows a colon.
+			return "{{" + strings.TrimSpace(strings.SplitN(best.text, ":", 2)[0]) + "}}"
+		},
+	)
+	if err == nil && blanks == text.Text {
+		err = fmt.Errorf("no embedded answers")
+	}
+	return moodleText(&moodleTextSt{text.Format, blanks}), err
+}
+
+func ProcessIncludeMoodle(section JSONSectionSt, assetdir string) {
+	processBankInclude(section, assetdir, section.bankIncludes()[1])
+}
+
+// ExportMoodle writes the question pools of sections as a Moodle XML bank,
+// each section in a category under title.
+func ExportMoodle(w io.Writer, title string, sections []SectionSt) error {
+	quiz := moodleQuizSt{}
+	for _, section := range sections {
+		head := section.GetHead()
+		name := ternary(head.SectionTitle != "", head.SectionTitle, head.Type)
+		pool, found := poolQuestions(section)
+		if !found {
+			log.Printf("Warning: %s section %s has no questions Moodle can ask, left out\n", head.Type, name)
+			continue
+		}
+
+		quiz.Questions = append(quiz.Questions, moodleQuestionSt{
+			Type: "category",
+			Category: &moodleTextSt{Text: fmt.Sprintf("$course$/%s/%s",
+				strings.ReplaceAll(title, "/", "//"), strings.ReplaceAll(name, "/", "//"))},
+		})
+		points, err := pool.poolPoints(head.NumQuest, head.Points)
+		if err != nil {
+			return fmt.Errorf("section %s: %w", name, err)
+		}
+		pool.Each(
+			func(qi int, q *QuestionsSt) {
+				mq, err := moodleQuestion(q, head, points[qi])
+				if err != nil {
+					log.Printf("Warning: %s question %q %v, left out\n", name, q.Question.CleanString(), err)
+					return
+				}
+				mq.Name = &moodleTextSt{Text: fmt.Sprintf("%s %d", name, qi+1)}
+				quiz.Questions = append(quiz.Questions, mq)
+			},
+		)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(quiz); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// poolQuestions returns the pool a section draws its questions from, as
+// standalone questions.
+func poolQuestions(section SectionSt) (QuestionSetSt, bool) {
+	switch s := section.(type) {
+	case DBSectionSt:
+		return s.DBQuestions(), true
+	case *NumericSt:
+		return s.AllQuestions, true
+	case *ClozeSt:
+		return s.AllQuestions, true
+	case *WordProblemSt:
+		return s.AllQuestions, true
+	case *QuizSt:
+		return s.AllQuestions, true
+	case *ReadingCompSt:
+		return s.AllQuestions, true
+	case *CompQuestionsSt:
+		return s.AllQuestions, true
+	case *StimulusGroupsSt:
+		return s.AllQuestions, true
+	}
+	return QuestionSetSt{}, false
+}
+
+// moodleQuestion makes q a Moodle question worth points.
+func moodleQuestion(q *QuestionsSt, head *SectionHeadSt, points uint) (moodleQuestionSt, error) {
+	mq := moodleQuestionSt{
+		QuestionText: moodleHTML(q.Question.string),
+		DefaultGrade: strconv.FormatUint(uint64(points), 10),
+	}
+	feedback := func(index int) *moodleTextSt {
+		if text := q.choiceFeedback(index); text != "" {
+			return moodleHTML(text)
+		}
+		return nil
+	}
+
+	switch {
+	case q.Formula != "":
+		return mq, fmt.Errorf("has parameters")
+	case len(q.Callouts) != 0 || q.Items.fixMissing().Size() != 0:
+		return mq, fmt.Errorf("has no Moodle question type")
+	case head.Type == "true-false":
+		mq.Type = "truefalse"
+		mq.Answers = []moodleAnswerSt{
+			{Fraction: ternary(q.True, "100", "0"), Text: "true", Feedback: feedback(0)},
+			{Fraction: ternary(q.True, "0", "100"), Text: "false", Feedback: feedback(1)},
+		}
+	case q.Numeric != nil || q.Value != nil:
+		answer := NumericAnswerSt{}
+		if q.Numeric != nil {
+			answer = *q.Numeric
+		} else {
+			answer, _ = q.numericAnswer(ToleranceSt{}, "")
+		}
+		low, high := answer.Range()
+		mq.Type = "numerical"
+		mq.Answers = []moodleAnswerSt{
+			{Fraction: "100", Text: numberString(answer.Value), Tolerance: numberString((high - low) / 2)},
+		}
+		if answer.Units != "" {
+			mq.Units = &moodleUnitsSt{[]moodleUnitSt{{Multiplier: "1", Name: answer.Units}}}
+		}
+	case strings.Contains(q.Question.string, fillinMarker):
+		mq.Type = "cloze"
+		worth := *q
+		worth.Points = points
+		parts := strings.Split(q.Question.string, fillinMarker)
+		for pi := 1; pi < len(parts); pi++ {
+			answer, _ := q.Answers.fixMissing().Get(pi - 1)
+			parts[pi] = fmt.Sprintf("{%d:SHORTANSWER:=%s}", genfuncs.Max(worth.blankPoints(pi-1), 1),
+				moodleEscaper.Replace(answer)) + parts[pi]
+		}
+		mq.QuestionText = moodleHTML(strings.Join(parts, ""))
+	case q.Choices.fixMissing().Size() != 0:
+		mq.Type = "multichoice"
+		mq.Single = ternary(q.selectAll(), "false", "true")
+		mq.ShuffleAnswers = ternary(q.KeepChoices, "false", "true")
+		right := float64(len(q.correctChoices()))
+		wrong := ternary(q.selectAll(), -100.0, -100*head.NegativeMarking)
+		q.Choices.Each(
+			func(ci int, choice string) {
+				mq.Answers = append(mq.Answers, moodleAnswerSt{
+					Fraction: moodleFraction(ternary(q.isCorrect(ci), 100/right, wrong)),
+					Format:   "html",
+					Text:     moodleHTML(choice).Text,
+					Feedback: feedback(ci),
+				})
+			},
+		)
+	case q.Answers.fixMissing().Size() != 0 && head.Type != "essay":
+		mq.Type = "shortanswer"
+		for _, answer := range q.Answers.Values() {
+			mq.Answers = append(mq.Answers, moodleAnswerSt{Fraction: "100", Text: answer})
+		}
+	default:
+		mq.Type, mq.ResponseFormat = "essay", "editor"
+		if q.Answers.fixMissing().Size() != 0 {
+			mq.GraderInfo = moodleHTML(strings.Join(q.Answers.Values(), "\n"))
+		}
+	}
+	return mq, nil
+}
+
+// moodleHTML is text as Moodle's HTML text.
+func moodleHTML(text string) *moodleTextSt {
+	return &moodleTextSt{Format: "html", Text: strings.ReplaceAll(html.EscapeString(text), "\n", "<br>")}
+}
+
+// moodleFraction is the percentage of a question's points an answer earns,
+// as precise as Moodle's grades.
+func moodleFraction(percent float64) string {
+	if percent == 0 {
+		return "0"
+	}
+	return strings.TrimSuffix(strings.TrimRight(strconv.FormatFloat(percent, 'f', 5, 64), "0"), ".")
+}
+
+// ConvertMoodle turns the Moodle XML bank filePath into include files in
+// outDir, returning the files written.
+func ConvertMoodle(filePath, outDir string) ([]string, error) {
+	items, err := readMoodle(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return writeBankIncludes(items, outDir, strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)))
+}
@@ -0,0 +1,63 @@
+package testparts
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A choice question with "correct", a list of choice numbers, takes every
+// one of them as its answer and asks students to select all that apply. The
+// question is only right when exactly those choices are picked.
+
+const SelectAllNote = "(Select all that apply.)"
+
+func (q *QuestionsSt) selectAll() bool {
+	return len(q.Correct) != 0
+}
+
+// isCorrect reports whether the choice at index, counting from zero, is a
+// right answer.
+func (q *QuestionsSt) isCorrect(index int) bool {
+	if q.selectAll() {
+		return slices.Contains(q.Correct, uint(index+1))
+	}
+	return uint(index+1) == q.Answer
+}
+
+// correctChoices returns the text of every right choice.
+func (q *QuestionsSt) correctChoices() []string {
+	choices := make([]string, 0)
+	q.Choices.Each(
+		func(ci int, choice string) {
+			if q.isCorrect(ci) {
+				choices = append(choices, choice)
+			}
+		},
+	)
+	return choices
+}
+
+func (qs QuestionSetSt) hasSelectAll() bool {
+	return qs.List != nil && qs.Any(
+		func(_ int, q *QuestionsSt) bool {
+			return q.selectAll()
+		},
+	)
+}
+
+// selectAllKey lists the answer letters of questions numbered from start,
+// for sections whose answers do not fit in an answer box.
+func selectAllKey(questions QuestionSetSt, start uint32) []string {
+	return []string{
+		`\begin{enumerate}`,
+		fmt.Sprintf(`\setcounter{enumi}{%d}`, start-1),
+		`\large`,
+		strings.Join(stringsUsing(questions.Values(), func(q *QuestionsSt) string {
+			letters, _ := q.Answers.Get(0)
+			return fmt.Sprintf(`\item %s`, strings.Join(strings.Split(letters, ""), ", "))
+		}), "\n"),
+		`\normalsize`,
+		`\end{enumerate}`,
+	}
+}
@@ -0,0 +1,188 @@
+package testparts
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A numeric question's answer is its "value", or for a parameterized
+// question the result of its formula, and any response within "tolerance"
+// of it is right. The tolerance is an amount, 0.05, or a percentage of the
+// answer, "2%"; the section's tolerance and "units" apply to questions that
+// do not give their own. A response may leave out the units but any it
+// gives must match.
+
+const (
+	// numericFormsAnswers is the most values a Google Forms short answer
+	// question is given to accept a range.
+	numericFormsAnswers = 25
+
+	// numericPrecision is how many decimals answers and ranges are printed
+	// with, at most.
+	numericPrecision = 6
+)
+
+var numericResponseRe = regexp.MustCompile(
+	`^([-+−]?(?:\d{1,3}(?:,\d{3})+(?:\.\d*)?|\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?)\s*(.*)$`)
+
+type ToleranceSt struct {
+	Amount  float64
+	Percent bool
+}
+
+// UnmarshalJSON accepts a number or a percentage such as "2%".
+func (t *ToleranceSt) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &t.Amount); err == nil {
+		return nil
+	}
+	percent := ""
+	if err := json.Unmarshal(data, &percent); err != nil {
+		return err
+	}
+	amount, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(percent, "%")), 64)
+	if err != nil || !strings.HasSuffix(percent, "%") {
+		return fmt.Errorf("tolerance %q is not a number or a percentage", percent)
+	}
+	t.Amount, t.Percent = amount, true
+	return nil
+}
+
+// NumericAnswerSt is the answer to a numeric question.
+type NumericAnswerSt struct {
+	Value     float64
+	Tolerance ToleranceSt
+	Units     string
+}
+
+// Range returns the lowest and highest values accepted.
+func (na NumericAnswerSt) Range() (float64, float64) {
+	delta := na.Tolerance.Amount
+	if na.Tolerance.Percent {
+		delta = math.Abs(na.Value) * na.Tolerance.Amount / 100
+	}
+	return na.Value - delta, na.Value + delta
+}
+
+// Grade reports whether response, a number optionally followed by units,
+// is within the answer's tolerance.
+func (na NumericAnswerSt) Grade(response string) bool {
+	value, units, err := parseNumericResponse(response)
+	if err != nil {
+		return false
+	}
+	if units != "" && compactUnits(units) != compactUnits(na.Units) {
+		return false
+	}
+	low, high := na.Range()
+	// Allow for the rounding of values printed in the key.
+	margin := math.Pow10(-numericPrecision) / 2
+	return value >= low-margin && value <= high+margin
+}
+
+// String is the answer as the key shows it, with the accepted range.
+func (na NumericAnswerSt) String() string {
+	answer := strings.TrimSpace(numberString(na.Value) + " " + na.Units)
+	if na.Tolerance.Amount == 0 {
+		return answer
+	}
+	low, high := na.Range()
+	return fmt.Sprintf("%s (%s to %s)", answer, numberString(low), numberString(high))
+}
+
+// formsAnswers lists the responses a Google Forms short answer question
+// accepts: every value in range at the answer's precision when there are
+// few enough, otherwise only the exact answer.
+func (na NumericAnswerSt) formsAnswers() []string {
+	values := []string{numberString(na.Value)}
+	decimals := 0
+	if dot := strings.Index(values[0], "."); dot >= 0 {
+		decimals = len(values[0]) - dot - 1
+	}
+	step := math.Pow10(-decimals)
+	low, high := na.Range()
+	first, last := math.Ceil(low/step-1e-9), math.Floor(high/step+1e-9)
+	if na.Tolerance.Amount != 0 && last-first+1 <= numericFormsAnswers {
+		values = values[:0]
+		for n := first; n <= last; n++ {
+			values = append(values, strconv.FormatFloat(n*step, 'f', decimals, 64))
+		}
+	}
+
+	answers := make([]string, 0, len(values)*3)
+	for _, value := range values {
+		answers = append(answers, value)
+		if na.Units != "" {
+			units := MathUnicode(na.Units)
+			answers = append(answers, value+" "+units, value+compactUnits(units))
+		}
+	}
+	return answers
+}
+
+func parseNumericResponse(response string) (float64, string, error) {
+	match := numericResponseRe.FindStringSubmatch(strings.TrimSpace(response))
+	if match == nil {
+		return 0, "", fmt.Errorf("%q is not a number", response)
+	}
+	number := strings.ReplaceAll(strings.ReplaceAll(match[1], ",", ""), "−", "-")
+	value, err := strconv.ParseFloat(number, 64)
+	return value, strings.TrimSpace(match[2]), err
+}
+
+func compactUnits(units string) string {
+	return strings.Join(strings.Fields(MathUnicode(units)), "")
+}
+
+func numberString(value float64) string {
+	scale := math.Pow10(numericPrecision)
+	return strconv.FormatFloat(math.Round(value*scale)/scale, 'f', -1, 64)
+}
+
+// numericAnswer works out the answer to a question of a numeric section
+// with the given tolerance and units.
+func (q *QuestionsSt) numericAnswer(tolerance ToleranceSt, units string) (NumericAnswerSt, error) {
+	answer := NumericAnswerSt{
+		Tolerance: tolerance,
+		Units:     ternary(q.Units != "", q.Units, units),
+	}
+	if q.Tolerance != nil {
+		answer.Tolerance = *q.Tolerance
+	}
+	if q.Value != nil {
+		answer.Value = *q.Value
+		return answer, nil
+	}
+
+	text, _ := q.Answers.fixMissing().Get(0)
+	value, _, err := parseNumericResponse(text)
+	if err != nil {
+		return answer, fmt.Errorf("no value, and the answer %q is not a number", text)
+	}
+	answer.Value = value
+	return answer, nil
+}
+
+func (q *QuestionsSt) numericUnits() string {
+	if q.Numeric == nil {
+		return ""
+	}
+	return q.Numeric.Units
+}
+
+// Grade scores a student's responses to the section, in question order,
+// returning the points earned.
+func (n *NumericSt) Grade(student uint, responses []string) uint {
+	points := uint(0)
+	n.Questions.get(student).Each(
+		func(qi int, q *QuestionsSt) {
+			if qi < len(responses) && q.Numeric != nil && q.Numeric.Grade(responses[qi]) {
+				points += q.Points
+			}
+		},
+	)
+	return points
+}
@@ -0,0 +1,83 @@
+package testparts
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+)
+
+// An ordering question lists its "items" in the right order. Each student
+// gets them lettered in a shuffled order, never the right one, and writes
+// the letters in order. A response earns a share of the question's points
+// for every pair of items it puts next to each other in the right order.
+
+// makeOrdering turns an ordering question's items into choices to shuffle.
+func (q *QuestionsSt) makeOrdering() {
+	q.Choices = WordsSt{List: arraylist.New(q.Items.fixMissing().Values()...)}
+	q.Answer = 0
+	q.Correct = nil
+	q.Ordering = true
+}
+
+// orderLetters returns the letters of the items as shown, in the right
+// order.
+func (q *QuestionsSt) orderLetters() []string {
+	letters := make([]string, len(q.Sequence))
+	for ci, from := range q.Sequence {
+		letters[from] = fmt.Sprintf("%c", 'A'+ci)
+	}
+	return letters
+}
+
+// orderScore returns the points earned by response, the letters of the
+// items in the order the student put them.
+func (q *QuestionsSt) orderScore(response string) float64 {
+	if len(q.Sequence) < 2 {
+		return 0
+	}
+	places := make([]int, 0, len(q.Sequence))
+	for _, letter := range strings.ToUpper(response) {
+		if !unicode.IsLetter(letter) {
+			continue
+		}
+		place := -1
+		if ci := int(letter - 'A'); ci >= 0 && ci < len(q.Sequence) {
+			place = q.Sequence[ci]
+		}
+		places = append(places, place)
+	}
+
+	// A letter written twice does not earn its pair twice.
+	pairs := map[int]bool{}
+	for i := 1; i < len(places); i++ {
+		if places[i-1] >= 0 && places[i] == places[i-1]+1 {
+			pairs[places[i-1]] = true
+		}
+	}
+	return float64(q.Points) * float64(len(pairs)) / float64(len(q.Sequence)-1)
+}
+
+// orderBlanks is a blank for each item's letter.
+func (q *QuestionsSt) orderBlanks(blank string) string {
+	blanks := make([]string, len(q.Sequence))
+	for i := range blanks {
+		blanks[i] = blank
+	}
+	return strings.Join(blanks, " ")
+}
+
+// Grade scores a student's responses to the section, in question order,
+// returning the points earned with partial credit.
+func (o *OrderingSt) Grade(student uint, responses []string) float64 {
+	points := 0.0
+	o.Questions.get(student).Each(
+		func(qi int, q *QuestionsSt) {
+			if qi < len(responses) {
+				points += q.orderScore(responses[qi])
+			}
+		},
+	)
+	return points
+}
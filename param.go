@@ -0,0 +1,335 @@
+package testparts
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"strconv"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+)
+
+// A parameterized question draws its numbers for each student. Anywhere in
+// the question, its parts, choices or answers:
+//
+//   - {{a:int 3..12}} draws a whole number, {{p:money 0.5..2}} an amount in
+//     cents and {{x:num 1..10}} a number to one decimal place, and prints it;
+//   - {{a}} prints a variable drawn elsewhere in the question;
+//   - {{a*p + 1}} prints the value of a formula over the variables.
+//
+// "formula" gives the answer and "distractors" formulas for common
+// mistakes; with distractors the question becomes multiple choice. Formulas
+// use + - * / ^ and parentheses, and any result involving money is printed
+// with two decimals.
+
+var (
+	templateRe     = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+	variableNameRe = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+	variableRe     = regexp.MustCompile(
+		`^([A-Za-z_]\w*)\s*:\s*(int|money|num)\s+(-?[\d.]+)\s*\.\.\s*(-?[\d.]+)$`)
+)
+
+type paramValueSt struct {
+	num   float64
+	money bool
+}
+
+func (v paramValueSt) String() string {
+	if v.money {
+		return strconv.FormatFloat(v.num, 'f', 2, 64)
+	}
+	return strconv.FormatFloat(math.Round(v.num*100)/100, 'f', -1, 64)
+}
+
+type paramVarsSt map[string]paramValueSt
+
+func (q *QuestionsSt) isParameterized() bool {
+	if q.Formula != "" {
+		return true
+	}
+	for _, text := range q.templateTexts() {
+		for _, match := range templateRe.FindAllStringSubmatch(text, -1) {
+			if variableRe.MatchString(match[1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// templateTexts lists every text of the question in the order variables
+// are drawn.
+func (q *QuestionsSt) templateTexts() []string {
+	texts := []string{q.Question.string}
+	if q.Parts.List != nil {
+		texts = append(texts, q.Parts.values()...)
+	}
+	if q.Choices.List != nil {
+		texts = append(texts, q.Choices.Values()...)
+	}
+	if q.Answers.List != nil {
+		texts = append(texts, q.Answers.Values()...)
+	}
+	return texts
+}
+
+// instantiate fills newQuest with template's text for one student, drawing
+// the variables from rnd.
+func (newQuest *QuestionsSt) instantiate(template *QuestionsSt, rnd *rand.Rand) error {
+	vars, err := drawVariables(template.templateTexts(), rnd)
+	if err != nil {
+		return err
+	}
+
+	text, err := vars.fill(template.Question.string)
+	if err != nil {
+		return err
+	}
+	newQuest.Question = NLStringSt{text}
+
+	parts := arraylist.New[NLStringSt]()
+	for _, part := range newQuest.Parts.values() {
+		text, err := vars.fill(part)
+		if err != nil {
+			return err
+		}
+		parts.Add(NLStringSt{text})
+	}
+	newQuest.Parts = NLStringListSt{List: parts}
+
+	if newQuest.Choices, err = vars.fillAll(newQuest.Choices); err != nil {
+		return err
+	}
+	if newQuest.Answers, err = vars.fillAll(newQuest.Answers); err != nil {
+		return err
+	}
+	if template.Formula == "" {
+		return nil
+	}
+
+	answer, err := vars.eval(template.Formula)
+	if err != nil {
+		return fmt.Errorf("formula: %v", err)
+	}
+	newQuest.Answers = WordsSt{List: arraylist.New(answer.String())}
+
+	if template.Distractors.List == nil || template.Distractors.Size() == 0 {
+		return nil
+	}
+	choices := arraylist.New(answer.String())
+	for _, distractor := range template.Distractors.Values() {
+		value, err := vars.eval(distractor)
+		if err != nil {
+			return fmt.Errorf("distractor %s: %v", distractor, err)
+		}
+		if !choices.Contains(value.String()) {
+			choices.Add(value.String())
+		}
+	}
+	newQuest.Choices = WordsSt{List: choices}
+	newQuest.Answer = 1
+	return nil
+}
+
+func drawVariables(texts []string, rnd *rand.Rand) (paramVarsSt, error) {
+	vars := paramVarsSt{}
+	for _, text := range texts {
+		for _, match := range templateRe.FindAllStringSubmatch(text, -1) {
+			def := variableRe.FindStringSubmatch(match[1])
+			if def == nil {
+				continue
+			}
+			if _, found := vars[def[1]]; found {
+				return nil, fmt.Errorf("variable %s is defined twice", def[1])
+			}
+			value, err := drawValue(def[2], def[3], def[4], rnd)
+			if err != nil {
+				return nil, fmt.Errorf("variable %s: %v", def[1], err)
+			}
+			vars[def[1]] = value
+		}
+	}
+	return vars, nil
+}
+
+func drawValue(kind, from, to string, rnd *rand.Rand) (paramValueSt, error) {
+	low, lowErr := strconv.ParseFloat(from, 64)
+	high, highErr := strconv.ParseFloat(to, 64)
+	if lowErr != nil || highErr != nil || low > high {
+		return paramValueSt{}, fmt.Errorf("%s..%s is not a range", from, to)
+	}
+
+	scale := map[string]float64{"int": 1, "money": 100, "num": 10}[kind]
+	first, last := math.Ceil(low*scale), math.Floor(high*scale)
+	if first > last {
+		return paramValueSt{}, fmt.Errorf("%s..%s holds no %s values", from, to, kind)
+	}
+	step := first + float64(rnd.Int63n(int64(last-first)+1))
+	return paramValueSt{num: step / scale, money: kind == "money"}, nil
+}
+
+// fill replaces every template in text with its value.
+func (vars paramVarsSt) fill(text string) (string, error) {
+	var fillErr error
+	filled := templateRe.ReplaceAllStringFunc(text,
+		func(template string) string {
+			expr := templateRe.FindStringSubmatch(template)[1]
+			if def := variableRe.FindStringSubmatch(expr); def != nil {
+				expr = def[1]
+			}
+			value, err := vars.eval(expr)
+			if err != nil {
+				fillErr = fmt.Errorf("%s: %v", template, err)
+				return template
+			}
+			return value.String()
+		},
+	)
+	return filled, fillErr
+}
+
+func (vars paramVarsSt) fillAll(words WordsSt) (WordsSt, error) {
+	filled := arraylist.New[string]()
+	for _, word := range words.fixMissing().Values() {
+		text, err := vars.fill(word)
+		if err != nil {
+			return words, err
+		}
+		filled.Add(text)
+	}
+	return WordsSt{List: filled}, nil
+}
+
+// ---- Formulas ----
+
+func (vars paramVarsSt) eval(expr string) (paramValueSt, error) {
+	p := &formulaParserSt{vars: vars, tokens: formulaTokens(expr)}
+	value, err := p.sum()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	if err == nil && (math.IsInf(value.num, 0) || math.IsNaN(value.num)) {
+		err = fmt.Errorf("%s has no value", expr)
+	}
+	return value, err
+}
+
+func formulaTokens(expr string) []string {
+	tokens := make([]string, 0)
+	for i := 0; i < len(expr); {
+		switch ch := expr[i]; {
+		case ch == ' ' || ch == '\t':
+			i++
+		case isFormulaWord(ch):
+			start := i
+			for i < len(expr) && isFormulaWord(expr[i]) {
+				i++
+			}
+			tokens = append(tokens, expr[start:i])
+		default:
+			tokens = append(tokens, expr[i:i+1])
+			i++
+		}
+	}
+	return tokens
+}
+
+func isFormulaWord(ch byte) bool {
+	return ch >= '0' && ch <= '9' || ch == '.' || ch == '_' ||
+		ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+type formulaParserSt struct {
+	vars   paramVarsSt
+	tokens []string
+	pos    int
+}
+
+func (p *formulaParserSt) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *formulaParserSt) sum() (paramValueSt, error) {
+	left, err := p.product()
+	for err == nil && (p.peek() == "+" || p.peek() == "-") {
+		op := p.tokens[p.pos]
+		p.pos++
+		var right paramValueSt
+		if right, err = p.product(); err == nil {
+			left = paramValueSt{
+				num:   ternary(op == "+", left.num+right.num, left.num-right.num),
+				money: left.money || right.money,
+			}
+		}
+	}
+	return left, err
+}
+
+func (p *formulaParserSt) product() (paramValueSt, error) {
+	left, err := p.power()
+	for err == nil && (p.peek() == "*" || p.peek() == "/") {
+		op := p.tokens[p.pos]
+		p.pos++
+		var right paramValueSt
+		if right, err = p.power(); err == nil {
+			left = paramValueSt{
+				num:   ternary(op == "*", left.num*right.num, left.num/right.num),
+				money: left.money || right.money,
+			}
+		}
+	}
+	return left, err
+}
+
+func (p *formulaParserSt) power() (paramValueSt, error) {
+	base, err := p.unary()
+	if err == nil && p.peek() == "^" {
+		p.pos++
+		var exp paramValueSt
+		if exp, err = p.power(); err == nil {
+			base = paramValueSt{
+				num:   math.Pow(base.num, exp.num),
+				money: base.money || exp.money,
+			}
+		}
+	}
+	return base, err
+}
+
+func (p *formulaParserSt) unary() (paramValueSt, error) {
+	token := p.peek()
+	p.pos++
+	switch {
+	case token == "":
+		return paramValueSt{}, fmt.Errorf("formula ends early")
+	case token == "-":
+		value, err := p.unary()
+		value.num = -value.num
+		return value, err
+	case token == "(":
+		value, err := p.sum()
+		if err == nil && p.peek() != ")" {
+			err = fmt.Errorf("missing )")
+		}
+		p.pos++
+		return value, err
+	case token[0] >= '0' && token[0] <= '9' || token[0] == '.':
+		num, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return paramValueSt{}, fmt.Errorf("%s is not a number", token)
+		}
+		return paramValueSt{num: num}, nil
+	case !variableNameRe.MatchString(token):
+		return paramValueSt{}, fmt.Errorf("unexpected %q", token)
+	}
+
+	value, found := p.vars[token]
+	if !found {
+		return paramValueSt{}, fmt.Errorf("unknown variable %s", token)
+	}
+	return value, nil
+}
@@ -0,0 +1,57 @@
+package testparts
+
+import "testing"
+
+func TestFormulaPrecedence(t *testing.T) {
+	vars := paramVarsSt{"a": {num: 3}, "b": {num: 4}, "p": {num: 1.5, money: true}}
+	tests := []struct {
+		expr  string
+		want  float64
+		money bool
+	}{
+		{"1 + 2 * 3", 7, false},
+		{"(1 + 2) * 3", 9, false},
+		{"10 - 4 - 3", 3, false},
+		{"24 / 4 / 2", 3, false},
+		{"2 * 3 ^ 2", 18, false},
+		{"2 ^ 3 ^ 2", 512, false},
+		{"-a + b", 1, false},
+		{"a * -b", -12, false},
+		{"a*b - b/2", 10, false},
+		{"((a))", 3, false},
+		{"p * a", 4.5, true},
+		{"a + b * p", 9, true},
+	}
+	for _, tt := range tests {
+		got, err := vars.eval(tt.expr)
+		if err != nil {
+			t.Errorf("eval(%q): %v", tt.expr, err)
+			continue
+		}
+		if got.num != tt.want || got.money != tt.money {
+			t.Errorf("eval(%q) = %v money %v, want %v money %v", tt.expr, got.num, got.money, tt.want, tt.money)
+		}
+	}
+}
+
+func TestFormulaErrors(t *testing.T) {
+	vars := paramVarsSt{"a": {num: 3}, "z": {num: 0}}
+	tests := []string{
+		"a / 0",
+		"a / z",
+		"z / z",
+		"a / (z * 2)",
+		"0 ^ -1",
+		"a +",
+		"(a + 1",
+		"a b",
+		"c * 2",
+		"a % 2",
+		"",
+	}
+	for _, expr := range tests {
+		if got, err := vars.eval(expr); err == nil {
+			t.Errorf("eval(%q) = %v, want an error", expr, got.num)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package testparts
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+)
+
+// A passage-completion section's "word-list" holds the word for each blank
+// of its passage, in order. Each copy letters the words in a shuffled order
+// and every blank becomes a question choosing from them, so the blanks can
+// be charted, shown as dropdowns in Google Forms and stored for the live
+// quiz, sharing the section's points.
+
+// blankQuestions makes a question for each blank, its answer from answers,
+// choosing from words.
+func (p *PassageCompletionSt) blankQuestions(words NLStringListSt, answers []string) QuestionSetSt {
+	choices := make([]string, 0, words.Size())
+	words.Each(
+		func(_ int, word NLStringSt) {
+			choices = append(choices, word.string)
+		},
+	)
+	questions := QuestionSetSt{List: arraylist.New[*QuestionsSt]()}
+	for bi, answer := range answers {
+		questions.Add(
+			&QuestionsSt{
+				Question:    NLStringSt{fmt.Sprintf("Blank %d", bi+1)},
+				Choices:     WordsSt{List: arraylist.New(choices...)},
+				Answer:      uint(slices.Index(choices, answer) + 1),
+				KeepChoices: true,
+				Dropdown:    true,
+			},
+		)
+	}
+	questions.allocatePoints(p.Points)
+	return questions
+}
+
+// DBQuestions is the section's blanks as stored for the live quiz, each
+// asked with the passage since the quiz shows questions on their own.
+func (p *PassageCompletionSt) DBQuestions() QuestionSetSt {
+	text := NLStringSt{p.Text}
+	passage := text.CleanString()
+	questions := QuestionSetSt{List: arraylist.New[*QuestionsSt]()}
+	p.AllQuestions.Each(
+		func(_ int, q *QuestionsSt) {
+			blank := *q
+			blank.Question = NLStringSt{passage + "\n\n" + q.Question.string}
+			questions.Add(&blank)
+		},
+	)
+	return questions
+}
@@ -0,0 +1,218 @@
+package testparts
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+	"github.com/nwillc/genfuncs"
+)
+
+// A section's "points" are shared by the questions on each student's copy.
+// A question's "points" fix what it is worth and the questions without
+// points split what is left evenly, the first ones on the copy taking a
+// point more when it does not divide. A question with "partPoints" is worth
+// their sum and each of its parts is marked on its own. When the section
+// has no points and every question has its own, the section is worth what
+// the questions add up to.
+//
+// A section's "negativeMarking" is the fraction of a multiple choice
+// question's points taken off for a wrong answer; a blank answer costs
+// nothing.
+
+// fixedPoints returns what the question is worth if it says so itself.
+func (q *QuestionsSt) fixedPoints() (uint, bool) {
+	if q.Rubric != nil {
+		return q.Rubric.maxPoints(), true
+	}
+	if len(q.PartPoints) != 0 {
+		return sumPoints(q.PartPoints), true
+	}
+	return q.Points, q.Points != 0
+}
+
+func (qs QuestionSetSt) hasFixedPoints() bool {
+	return qs.List != nil && qs.Any(
+		func(_ int, q *QuestionsSt) bool {
+			_, fixed := q.fixedPoints()
+			return fixed
+		},
+	)
+}
+
+// partPoints returns the points of part index, or false if the parts are
+// not marked on their own.
+func (q *QuestionsSt) partPoints(index int) (uint, bool) {
+	if index < len(q.PartPoints) {
+		return q.PartPoints[index], true
+	}
+	return 0, false
+}
+
+// allocatePoints sets the points of every question on one copy so they add
+// up to total. sectionPoints has made sure they can.
+func (qs QuestionSetSt) allocatePoints(total uint) {
+	free := make([]*QuestionsSt, 0)
+	qs.Each(
+		func(_ int, q *QuestionsSt) {
+			if points, fixed := q.fixedPoints(); fixed {
+				q.Points = points
+				total -= genfuncs.Min(points, total)
+				return
+			}
+			free = append(free, q)
+		},
+	)
+	for i, q := range free {
+		q.Points = total / uint(len(free))
+		if uint(i) < total%uint(len(free)) {
+			q.Points++
+		}
+	}
+}
+
+// sectionPoints returns what every copy of numQuest questions drawn from
+// pool is worth, or an error when some copy's questions cannot add up to
+// the section's points.
+func sectionPoints(pool []*QuestionsSt, numQuest, points uint) (uint, error) {
+	fixed := make([]uint, 0)
+	for _, q := range pool {
+		if value, found := q.fixedPoints(); found {
+			fixed = append(fixed, value)
+		}
+	}
+	numQuest = genfuncs.Min(numQuest, uint(len(pool)))
+	if len(fixed) == 0 || numQuest == 0 {
+		return points, nil
+	}
+	slices.Sort(fixed)
+	slices.Reverse(fixed)
+
+	numFree := uint(len(pool) - len(fixed))
+	fewest := numQuest - genfuncs.Min(numQuest, numFree)
+	most := genfuncs.Min(uint(len(fixed)), numQuest)
+
+	if most == numQuest {
+		high, low := sumPoints(fixed[:numQuest]), sumPoints(fixed[uint(len(fixed))-numQuest:])
+		switch {
+		case high != low:
+			return points, fmt.Errorf("a copy of %d questions that all have points can be worth from %d to %d points",
+				numQuest, low, high)
+		case points == 0 && fewest == numQuest:
+			return high, nil
+		case points != high:
+			return points, fmt.Errorf("a copy of questions that all have points is worth %d points but the section is worth %d",
+				high, points)
+		}
+	}
+
+	// The questions without points on a copy share what is left.
+	if shared := genfuncs.Min(most, numQuest-1); shared >= fewest {
+		if high := sumPoints(fixed[:shared]); high > points {
+			return points, fmt.Errorf("the questions with points on a copy can be worth %d points but the section is only worth %d",
+				high, points)
+		}
+	}
+	return points, nil
+}
+
+// poolPoints returns what each question in the pool is worth wherever it
+// is drawn, in the live quiz and the exports: its own points, or the share
+// allocatePoints gives the questions without points on a copy. Every copy
+// must give them the same share, or no one value agrees with the copies.
+func (qs QuestionSetSt) poolPoints(numQuest, total uint) ([]uint, error) {
+	requiredFixed, fixed := make([]uint, 0), make([]uint, 0)
+	requiredFree, numFree := uint(0), uint(0)
+	qs.Each(
+		func(_ int, q *QuestionsSt) {
+			value, isFixed := q.fixedPoints()
+			switch {
+			case q.Required && isFixed:
+				requiredFixed = append(requiredFixed, value)
+			case q.Required:
+				requiredFree++
+			case isFixed:
+				fixed = append(fixed, value)
+			default:
+				numFree++
+			}
+		},
+	)
+	if numQuest == 0 || numQuest > uint(qs.Size()) {
+		numQuest = uint(qs.Size())
+	}
+	slices.Sort(fixed)
+	slices.Reverse(fixed)
+
+	// The copies drawing the most and the fewest points among each number
+	// of questions with points bound what the others share.
+	slots := numQuest - genfuncs.Min(numQuest, uint(len(requiredFixed))+requiredFree)
+	share, shared := uint(0), false
+	for drawn := slots - genfuncs.Min(slots, numFree); drawn <= genfuncs.Min(slots, uint(len(fixed))); drawn++ {
+		for _, values := range [][]uint{fixed[:drawn], fixed[uint(len(fixed))-drawn:]} {
+			copyQuestions := QuestionSetSt{List: arraylist.New[*QuestionsSt]()}
+			copyFixed := append(slices.Clone(requiredFixed), values...)
+			for _, value := range copyFixed {
+				copyQuestions.Add(&QuestionsSt{Points: value})
+			}
+			left := total - genfuncs.Min(total, sumPoints(copyFixed))
+			free := make([]*QuestionsSt, 0)
+			for i := uint(0); i < requiredFree+slots-drawn; i++ {
+				free = append(free, &QuestionsSt{})
+			}
+			copyQuestions.Add(free...)
+			copyQuestions.allocatePoints(total)
+
+			for _, q := range free {
+				switch {
+				case q.Points != free[0].Points:
+					return nil, fmt.Errorf("the %d questions without points on a copy share %d points unevenly, "+
+						"so the live quiz, Moodle and QTI cannot give them one value", len(free), left)
+				case !shared:
+					share, shared = q.Points, true
+				case q.Points != share:
+					return nil, fmt.Errorf("the questions without points are worth %d points on some copies and %d on others, "+
+						"so the live quiz, Moodle and QTI cannot give them one value", share, q.Points)
+				}
+			}
+		}
+	}
+
+	points := make([]uint, 0, qs.Size())
+	qs.Each(
+		func(_ int, q *QuestionsSt) {
+			value, isFixed := q.fixedPoints()
+			points = append(points, ternary(isFixed, value, share))
+		},
+	)
+	return points, nil
+}
+
+func sumPoints(points []uint) uint {
+	total := uint(0)
+	for _, value := range points {
+		total += value
+	}
+	return total
+}
+
+func pointsText(points uint) string {
+	return fmt.Sprintf("(%d %s)", points, ternary(points == 1, "point", "points"))
+}
+
+// instructions adds what a wrong answer costs to the section's
+// instructions.
+func (head *SectionHeadSt) instructions() string {
+	return strings.TrimSpace(head.Instructions + " " + head.markingNote())
+}
+
+// markingNote tells students what a wrong answer costs.
+func (head *SectionHeadSt) markingNote() string {
+	if head.NegativeMarking <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("Each wrong answer loses %s%% of its question's points; blank answers lose nothing.",
+		strconv.FormatFloat(head.NegativeMarking*100, 'f', -1, 64))
+}
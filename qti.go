@@ -0,0 +1,853 @@
+package testparts
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/daichi-m/go18ds/lists/arraylist"
+	"github.com/nwillc/genfuncs"
+)
+
+// ExportQTI writes a test as a zipped IMS QTI 2.1 content package: a
+// manifest, an assessment test with a section for each of the test's
+// sections drawing its questionsOntest from the section's pool, required
+// questions always, and an item for each pool question scored with its
+// points. Choice questions keep their feedback and negative marking,
+// numeric ones their tolerance, cloze ones a response for each blank, and
+// essays give their answer to scorers. Images with a file are packed with
+// the items that show them.
+//
+// "includeQTI" files are QTI 2.1 or 3.0 packages, or single item files.
+// Choice, text entry, extended text and match items are read like GIFT
+// ones: choices True and False make a true/false item, a float text entry
+// a numeric one and several text entries or inline choices a cloze one.
+// Items are numbered in their package for warnings, and points and images
+// are left out. ImportQTI reads a file as a question pool.
+
+var qtiNamespace = []string{
+	"xmlns", "http://www.imsglobal.org/xsd/imsqti_v2p1",
+	"xmlns:xsi", "http://www.w3.org/2001/XMLSchema-instance",
+	"xsi:schemaLocation", "http://www.imsglobal.org/xsd/imsqti_v2p1 " +
+		"http://www.imsglobal.org/xsd/qti/qtiv2p1/imsqti_v2p1p2.xsd",
+}
+
+var qtiManifestNamespace = []string{
+	"xmlns", "http://www.imsglobal.org/xsd/imscp_v1p1",
+	"xmlns:xsi", "http://www.w3.org/2001/XMLSchema-instance",
+	"xsi:schemaLocation", "http://www.imsglobal.org/xsd/imscp_v1p1 " +
+		"http://www.imsglobal.org/xsd/qti/qtiv2p1/qtiv2p1_imscpv1p2_v1p0.xsd",
+}
+
+var qtiSpaceRe = regexp.MustCompile(`\s+`)
+
+// qtiNodeSt is an element of a QTI document, or text when it has no name.
+type qtiNodeSt struct {
+	name     string
+	attrs    [][2]string
+	children []*qtiNodeSt
+	text     string
+}
+
+// qtiEl makes an element with attrs given as names and values.
+func qtiEl(name string, attrs ...string) *qtiNodeSt {
+	n := &qtiNodeSt{name: name}
+	for ai := 0; ai+1 < len(attrs); ai += 2 {
+		n.attrs = append(n.attrs, [2]string{attrs[ai], attrs[ai+1]})
+	}
+	return n
+}
+
+func qtiText(text string) *qtiNodeSt {
+	return &qtiNodeSt{text: text}
+}
+
+func (n *qtiNodeSt) add(children ...*qtiNodeSt) *qtiNodeSt {
+	for _, child := range children {
+		if child != nil {
+			n.children = append(n.children, child)
+		}
+	}
+	return n
+}
+
+func (n *qtiNodeSt) attr(name string) string {
+	if n == nil {
+		return ""
+	}
+	for _, attr := range n.attrs {
+		if attr[0] == name {
+			return attr[1]
+		}
+	}
+	return ""
+}
+
+// all returns the elements in n named name, n included, in document order.
+func (n *qtiNodeSt) all(name string) []*qtiNodeSt {
+	found := make([]*qtiNodeSt, 0)
+	if n == nil {
+		return found
+	}
+	if n.name == name {
+		found = append(found, n)
+	}
+	for _, child := range n.children {
+		found = append(found, child.all(name)...)
+	}
+	return found
+}
+
+func (n *qtiNodeSt) first(name string) *qtiNodeSt {
+	if found := n.all(name); len(found) != 0 {
+		return found[0]
+	}
+	return nil
+}
+
+// plainText is the text in n, a line for each paragraph, with the elements
+// replace picks replaced by what it returns.
+func (n *qtiNodeSt) plainText(replace func(*qtiNodeSt) (string, bool)) string {
+	var out strings.Builder
+	var collect func(*qtiNodeSt)
+	collect = func(node *qtiNodeSt) {
+		if node.name == "" {
+			out.WriteString(qtiSpaceRe.ReplaceAllString(node.text, " "))
+			return
+		}
+		if replace != nil {
+			if text, replaced := replace(node); replaced {
+				out.WriteString(text)
+				return
+			}
+		}
+		switch node.name {
+		case "p", "div", "br", "li", "prompt", "tr":
+			out.WriteString("\n")
+		}
+		for _, child := range node.children {
+			collect(child)
+		}
+	}
+	if n != nil {
+		collect(n)
+	}
+	return bankLines(out.String())
+}
+
+// write writes the element, its children indented below it unless they
+// are mixed with text.
+func (n *qtiNodeSt) write(out *bytes.Buffer, indent string, inline bool) {
+	if n.name == "" {
+		xml.EscapeText(out, []byte(n.text))
+		return
+	}
+	if !inline {
+		out.WriteString(indent)
+	}
+	out.WriteString("<" + n.name)
+	for _, attr := range n.attrs {
+		out.WriteString(" " + attr[0] + `="`)
+		xml.EscapeText(out, []byte(attr[1]))
+		out.WriteString(`"`)
+	}
+	mixed := inline || slices.ContainsFunc(n.children, func(child *qtiNodeSt) bool { return child.name == "" })
+	switch {
+	case len(n.children) == 0:
+		out.WriteString("/>")
+	case mixed:
+		out.WriteString(">")
+		for _, child := range n.children {
+			child.write(out, "", true)
+		}
+		out.WriteString("</" + n.name + ">")
+	default:
+		out.WriteString(">\n")
+		for _, child := range n.children {
+			child.write(out, indent+"  ", false)
+			out.WriteString("\n")
+		}
+		out.WriteString(indent + "</" + n.name + ">")
+	}
+}
+
+func (n *qtiNodeSt) document() []byte {
+	out := bytes.NewBufferString(xml.Header)
+	n.write(out, "", false)
+	out.WriteString("\n")
+	return out.Bytes()
+}
+
+// readQTINode reads a QTI document, naming QTI 3.0 elements and attributes
+// as QTI 2.1 does.
+func readQTINode(r io.Reader) (*qtiNodeSt, error) {
+	decoder := xml.NewDecoder(r)
+	stack := []*qtiNodeSt{{}}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line, column := decoder.InputPos()
+			return nil, ValidationErrorSt{Line: uint(line), Column: uint(column), Message: err.Error()}
+		}
+		top := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &qtiNodeSt{name: qtiName(t.Name.Local)}
+			for _, attr := range t.Attr {
+				n.attrs = append(n.attrs, [2]string{qtiName(attr.Name.Local), attr.Value})
+			}
+			top.add(n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			top.add(qtiText(string(t)))
+		}
+	}
+	for _, child := range stack[0].children {
+		if child.name != "" {
+			return child, nil
+		}
+	}
+	return nil, ValidationErrorSt{Line: 1, Column: 1, Message: "no document element"}
+}
+
+// qtiName is a QTI 3.0 name, "qti-simple-choice", as QTI 2.1 gives it,
+// "simpleChoice".
+func qtiName(name string) string {
+	parts := strings.Split(strings.TrimPrefix(name, "qti-"), "-")
+	for pi := 1; pi < len(parts); pi++ {
+		if parts[pi] != "" {
+			parts[pi] = strings.ToUpper(parts[pi][:1]) + parts[pi][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// readQTI reads the items of a QTI package or item file.
+func readQTI(filePath string) ([]bankItemSt, error) {
+	items := make([]bankItemSt, 0)
+	addItem := func(name string, r io.Reader) error {
+		root, err := readQTINode(r)
+		if qtiErr, isQTI := err.(ValidationErrorSt); isQTI {
+			qtiErr.File = name
+			return qtiErr
+		}
+		if err != nil || root.name != "assessmentItem" {
+			return err
+		}
+		item, err := qtiItem(root)
+		if err != nil {
+			return ValidationErrorSt{File: name, Line: 1, Column: 1,
+				Message: fmt.Sprintf("item %q: %v", root.attr("title"), err)}
+		}
+		if item != nil {
+			item.line = uint(len(items) + 1)
+			items = append(items, *item)
+		}
+		return nil
+	}
+
+	if !strings.EqualFold(filepath.Ext(filePath), ".zip") {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return items, addItem(filePath, file)
+	}
+
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	for _, file := range archive.File {
+		if !strings.EqualFold(filepath.Ext(file.Name), ".xml") || file.Name == "imsmanifest.xml" {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		err = addItem(filePath+"/"+file.Name, r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// qtiInteraction reports whether the element is one a student responds in.
+func qtiInteraction(n *qtiNodeSt) bool {
+	return strings.HasSuffix(n.name, "Interaction")
+}
+
+// qtiItem makes an assessment item a bank item, nil when it asks nothing
+// and one without a question when it asks what the package cannot.
+func qtiItem(root *qtiNodeSt) (*bankItemSt, error) {
+	body := root.first("itemBody")
+	if body == nil {
+		return nil, fmt.Errorf("no itemBody")
+	}
+	responses := map[string]*qtiNodeSt{}
+	for _, decl := range root.all("responseDeclaration") {
+		responses[decl.attr("identifier")] = decl
+	}
+	interactions := make([]*qtiNodeSt, 0)
+	inline := true
+	body.plainText(
+		func(n *qtiNodeSt) (string, bool) {
+			if qtiInteraction(n) {
+				interactions = append(interactions, n)
+				inline = inline && (n.name == "textEntryInteraction" || n.name == "inlineChoiceInteraction")
+			}
+			return "", qtiInteraction(n)
+		},
+	)
+	if len(interactions) == 0 {
+		return nil, nil
+	}
+
+	skip := func(n *qtiNodeSt) (string, bool) {
+		return "", qtiInteraction(n) || n.name == "rubricBlock" || n.name == "img" ||
+			strings.HasPrefix(n.name, "feedback")
+	}
+	interaction := interactions[0]
+	decl := responses[interaction.attr("responseIdentifier")]
+	item := &bankItemSt{kind: interaction.name, title: root.attr("title")}
+	item.before = strings.TrimSpace(body.plainText(skip) + "\n" + interaction.first("prompt").plainText(nil))
+
+	switch {
+	case inline && (len(interactions) > 1 || interaction.name == "inlineChoiceInteraction"):
+		item.kind = bankCloze
+		var err error
+		item.before = body.plainText(
+			func(n *qtiNodeSt) (string, bool) {
+				if !qtiInteraction(n) {
+					return skip(n)
+				}
+				answer := qtiClozeAnswer(n, responses[n.attr("responseIdentifier")])
+				if answer == "" {
+					err = fmt.Errorf("blank %s has no correct response", n.attr("responseIdentifier"))
+				}
+				return "{{" + answer + "}}", true
+			},
+		)
+		item.question = &QuestionsSt{Question: NLStringSt{item.before}}
+		return item, err
+	case len(interactions) > 1:
+	case interaction.name == "choiceInteraction":
+		answers := qtiChoiceAnswers(root, interaction, decl)
+		if len(answers) == 2 && strings.EqualFold(answers[0].text, "true") && strings.EqualFold(answers[1].text, "false") {
+			item.kind, item.question = bankTrueFalse, bankTrueFalseQuestion(answers)
+		} else {
+			item.kind, item.question = bankChoice, bankChoiceQuestion(answers, decl.attr("cardinality") == "multiple")
+		}
+	case interaction.name == "textEntryInteraction" && (decl.attr("baseType") == "float" || decl.attr("baseType") == "integer"):
+		item.kind = bankNumeric
+		correct := qtiCorrect(decl)
+		if len(correct) == 0 {
+			return item, fmt.Errorf("no correct response")
+		}
+		value, err := strconv.ParseFloat(correct[0], 64)
+		if err != nil {
+			return item, fmt.Errorf("%q is not a numeric answer", correct[0])
+		}
+		item.question = &QuestionsSt{Value: &value, Tolerance: qtiTolerance(root)}
+	case interaction.name == "textEntryInteraction":
+		answers := make([]bankAnswerSt, 0)
+		for _, answer := range qtiCorrect(decl) {
+			answers = append(answers, bankAnswerSt{text: answer, weight: 100})
+		}
+		item.kind, item.question = bankShort, bankShortQuestion(answers)
+		if inline {
+			text := body.plainText(
+				func(n *qtiNodeSt) (string, bool) {
+					if n == interaction {
+						return "\x00", true
+					}
+					return skip(n)
+				},
+			)
+			if before, after, found := strings.Cut(text, "\x00"); found {
+				item.before, item.after = strings.TrimSpace(before), strings.TrimSpace(after)
+			}
+		}
+	case interaction.name == "extendedTextInteraction":
+		item.kind, item.question = bankEssay, &QuestionsSt{}
+		for _, rubric := range body.all("rubricBlock") {
+			if strings.Contains(rubric.attr("view"), "scorer") {
+				item.question.Answers = WordsSt{List: arraylist.New(rubric.plainText(nil))}
+			}
+		}
+	case interaction.name == "matchInteraction":
+		item.kind, item.question = bankMatching, &QuestionsSt{}
+		texts := map[string]string{}
+		for _, choice := range interaction.all("simpleAssociableChoice") {
+			texts[choice.attr("identifier")] = choice.plainText(skip)
+		}
+		for _, pair := range qtiCorrect(decl) {
+			if ids := strings.Fields(pair); len(ids) == 2 {
+				item.pairs = append(item.pairs, [2]string{texts[ids[0]], texts[ids[1]]})
+			}
+		}
+	}
+	return item, nil
+}
+
+// qtiCorrect returns the values of a response that earn credit: its correct
+// response and the keys its mapping gives points.
+func qtiCorrect(decl *qtiNodeSt) []string {
+	values := make([]string, 0)
+	for _, value := range decl.first("correctResponse").all("value") {
+		values = append(values, strings.TrimSpace(value.plainText(nil)))
+	}
+	for _, entry := range decl.first("mapping").all("mapEntry") {
+		mapped, _ := strconv.ParseFloat(entry.attr("mappedValue"), 64)
+		if mapped > 0 && !slices.Contains(values, entry.attr("mapKey")) {
+			values = append(values, entry.attr("mapKey"))
+		}
+	}
+	return values
+}
+
+// qtiChoiceAnswers reads the choices with the feedback shown inline or for
+// choosing them.
+func qtiChoiceAnswers(root, interaction, decl *qtiNodeSt) []bankAnswerSt {
+	correct := qtiCorrect(decl)
+	feedback := map[string]string{}
+	for _, modal := range root.all("modalFeedback") {
+		feedback[modal.attr("identifier")] = modal.plainText(nil)
+	}
+
+	answers := make([]bankAnswerSt, 0)
+	for _, choice := range interaction.all("simpleChoice") {
+		id := choice.attr("identifier")
+		answer := bankAnswerSt{
+			weight: ternary(slices.Contains(correct, id), 100.0, 0.0),
+			text: choice.plainText(
+				func(n *qtiNodeSt) (string, bool) {
+					return "", strings.HasPrefix(n.name, "feedback")
+				},
+			),
+		}
+		for _, inline := range append(choice.all("feedbackInline"), choice.all("feedbackBlock")...) {
+			answer.feedback = append(answer.feedback, inline.plainText(nil))
+		}
+		if len(answer.feedback) == 0 && feedback[id] != "" {
+			answer.feedback = []string{feedback[id]}
+		}
+		answers = append(answers, answer)
+	}
+	return answers
+}
+
+// qtiTolerance reads the tolerance of the first comparison in the item's
+// response processing.
+func qtiTolerance(root *qtiNodeSt) *ToleranceSt {
+	tolerance := &ToleranceSt{}
+	equal := root.first("responseProcessing").first("equal")
+	if equal == nil {
+		return tolerance
+	}
+	if bounds := strings.Fields(equal.attr("tolerance")); len(bounds) != 0 {
+		tolerance.Amount, _ = strconv.ParseFloat(bounds[0], 64)
+		tolerance.Percent = equal.attr("toleranceMode") == "relative"
+	}
+	return tolerance
+}
+
+// qtiClozeAnswer is the answer to a blank: a text entry's correct response
+// or the text of an inline choice's.
+func qtiClozeAnswer(interaction, decl *qtiNodeSt) string {
+	correct := qtiCorrect(decl)
+	if len(correct) == 0 {
+		return ""
+	}
+	for _, choice := range interaction.all("inlineChoice") {
+		if choice.attr("identifier") == correct[0] {
+			return choice.plainText(nil)
+		}
+	}
+	return correct[0]
+}
+
+func ProcessIncludeQTI(section JSONSectionSt, assetdir string) {
+	processBankInclude(section, assetdir, section.bankIncludes()[2])
+}
+
+// ImportQTI reads the questions of a QTI package or item file, leaving out
+// matching items.
+func ImportQTI(filePath string) (QuestionSetSt, error) {
+	questions := QuestionSetSt{List: arraylist.New[*QuestionsSt]()}
+	items, err := readQTI(filePath)
+	if err != nil {
+		return questions, err
+	}
+	for _, item := range items {
+		if item.question != nil && item.kind != bankMatching {
+			questions.Add(item.questionFor(""))
+		}
+	}
+	return questions, nil
+}
+
+// qtiPackageSt is a QTI package being written.
+type qtiPackageSt struct {
+	zip    *zip.Writer
+	images map[string]string
+	files  []string
+}
+
+// image packs the image's file the first time it is shown, returning the
+// element showing it.
+func (pkg *qtiPackageSt) image(img *ImageSt) *qtiNodeSt {
+	if img == nil || (img.File == "" && img.URL == "") {
+		return nil
+	}
+	if img.File == "" {
+		return qtiEl("img", "src", img.URL, "alt", "")
+	}
+	href, found := pkg.images[img.File]
+	if !found {
+		data, err := os.ReadFile(img.File)
+		if err != nil {
+			log.Printf("Unable to read image %s, error: %v\n", img.File, err)
+			return nil
+		}
+		href = fmt.Sprintf("images/%d-%s", len(pkg.images)+1, filepath.Base(img.File))
+		if err := pkg.write(href, data); err != nil {
+			log.Printf("Unable to pack image %s, error: %v\n", img.File, err)
+			return nil
+		}
+		pkg.images[img.File] = href
+	}
+	if !slices.Contains(pkg.files, href) {
+		pkg.files = append(pkg.files, href)
+	}
+	return qtiEl("img", "src", href, "alt", "")
+}
+
+func (pkg *qtiPackageSt) write(name string, data []byte) error {
+	file, err := pkg.zip.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	return err
+}
+
+// ExportQTI writes sections as a zipped QTI package of a test titled title.
+func ExportQTI(w io.Writer, title string, sections []SectionSt) error {
+	pkg := &qtiPackageSt{zip: zip.NewWriter(w), images: map[string]string{}}
+	part := qtiEl("testPart", "identifier", "part-1", "navigationMode", "nonlinear",
+		"submissionMode", "simultaneous")
+	testResource := qtiEl("resource", "identifier", "test", "type", "imsqti_test_xmlv2p1", "href", "test.xml").
+		add(qtiEl("file", "href", "test.xml"))
+	itemResources := make([]*qtiNodeSt, 0)
+
+	for si, section := range sections {
+		head := section.GetHead()
+		name := ternary(head.SectionTitle != "", head.SectionTitle, head.Type)
+		pool, found := poolQuestions(section)
+		if !found {
+			log.Printf("Warning: %s section %s has no questions QTI can ask, left out\n", head.Type, name)
+			continue
+		}
+
+		refs := make([]*qtiNodeSt, 0)
+		points, err := pool.poolPoints(head.NumQuest, head.Points)
+		if err != nil {
+			return fmt.Errorf("section %s: %w", name, err)
+		}
+		pool.Each(
+			func(qi int, q *QuestionsSt) {
+				if err != nil {
+					return
+				}
+				id := fmt.Sprintf("item-%d-%d", si+1, qi+1)
+				pkg.files = []string{id + ".xml"}
+				item, itemErr := pkg.item(q, head, points[qi], id, fmt.Sprintf("%s %d", name, qi+1))
+				if itemErr != nil {
+					log.Printf("Warning: %s question %q %v, left out\n", name, q.Question.CleanString(), itemErr)
+					return
+				}
+				if err = pkg.write(id+".xml", item.document()); err != nil {
+					return
+				}
+
+				resource := qtiEl("resource", "identifier", id, "type", "imsqti_item_xmlv2p1", "href", id+".xml")
+				for _, file := range pkg.files {
+					resource.add(qtiEl("file", "href", file))
+				}
+				itemResources = append(itemResources, resource)
+				testResource.add(qtiEl("dependency", "identifierref", id))
+				ref := qtiEl("assessmentItemRef", "identifier", id, "href", id+".xml")
+				if q.Required {
+					ref.attrs = append(ref.attrs, [2]string{"required", "true"})
+				}
+				refs = append(refs, ref)
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if len(refs) == 0 {
+			continue
+		}
+
+		assessmentSection := qtiEl("assessmentSection", "identifier", fmt.Sprintf("section-%d", si+1),
+			"title", name, "visible", "true")
+		if head.NumQuest != 0 && head.NumQuest < uint(len(refs)) {
+			assessmentSection.add(qtiEl("selection", "select", strconv.FormatUint(uint64(head.NumQuest), 10)))
+		}
+		if !head.KeepOrder {
+			assessmentSection.add(qtiEl("ordering", "shuffle", "true"))
+		}
+		if head.Instructions != "" {
+			assessmentSection.add(qtiEl("rubricBlock", "view", "candidate").add(qtiParagraphs(head.Instructions)...))
+		}
+		part.add(assessmentSection.add(refs...))
+	}
+	if len(part.children) == 0 {
+		return fmt.Errorf("no section has questions QTI can ask")
+	}
+
+	test := qtiEl("assessmentTest", append(slices.Clone(qtiNamespace), "identifier", "test", "title", title)...).
+		add(qtiEl("outcomeDeclaration", "identifier", "SCORE", "cardinality", "single", "baseType", "float"),
+			part,
+			qtiEl("outcomeProcessing").add(
+				qtiEl("setOutcomeValue", "identifier", "SCORE").add(
+					qtiEl("sum").add(qtiEl("testVariables", "variableIdentifier", "SCORE")))))
+	manifest := qtiEl("manifest", append(slices.Clone(qtiManifestNamespace), "identifier", "manifest")...).
+		add(qtiEl("metadata").add(
+			qtiEl("schema").add(qtiText("QTIv2.1 Package")),
+			qtiEl("schemaversion").add(qtiText("1.0.0"))),
+			qtiEl("organizations"),
+			qtiEl("resources").add(append([]*qtiNodeSt{testResource}, itemResources...)...))
+	if err := pkg.write("test.xml", test.document()); err != nil {
+		return err
+	}
+	if err := pkg.write("imsmanifest.xml", manifest.document()); err != nil {
+		return err
+	}
+	return pkg.zip.Close()
+}
+
+// qtiParagraphs makes a paragraph of each line of text.
+func qtiParagraphs(text string) []*qtiNodeSt {
+	paragraphs := make([]*qtiNodeSt, 0)
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paragraphs = append(paragraphs, qtiEl("p").add(qtiText(line)))
+		}
+	}
+	return paragraphs
+}
+
+func qtiFloat(value float64) *qtiNodeSt {
+	return qtiEl("baseValue", "baseType", "float").add(qtiText(numberString(value)))
+}
+
+func qtiScore(value *qtiNodeSt) *qtiNodeSt {
+	return qtiEl("setOutcomeValue", "identifier", "SCORE").add(value)
+}
+
+// qtiResponse declares a response with its correct values.
+func qtiResponse(id, cardinality, baseType string, correct ...string) *qtiNodeSt {
+	decl := qtiEl("responseDeclaration", "identifier", id, "cardinality", cardinality, "baseType", baseType)
+	if len(correct) != 0 {
+		values := qtiEl("correctResponse")
+		for _, value := range correct {
+			values.add(qtiEl("value").add(qtiText(value)))
+		}
+		decl.add(values)
+	}
+	return decl
+}
+
+// qtiMapping gives each of answers points, ignoring case.
+func qtiMapping(answers []string, points float64) *qtiNodeSt {
+	mapping := qtiEl("mapping", "defaultValue", "0")
+	for _, answer := range answers {
+		mapping.add(qtiEl("mapEntry", "mapKey", answer, "mappedValue", numberString(points),
+			"caseSensitive", "false"))
+	}
+	return mapping
+}
+
+// qtiCondition scores points when test holds, otherwise nothing.
+func qtiCondition(test *qtiNodeSt, points uint) *qtiNodeSt {
+	return qtiEl("responseCondition").add(
+		qtiEl("responseIf").add(test, qtiScore(qtiFloat(float64(points)))),
+		qtiEl("responseElse").add(qtiScore(qtiFloat(0))))
+}
+
+// item makes q an assessment item worth points.
+func (pkg *qtiPackageSt) item(q *QuestionsSt, head *SectionHeadSt, points uint, id, title string) (*qtiNodeSt, error) {
+	decls := make([]*qtiNodeSt, 0)
+	outcomes := []*qtiNodeSt{
+		qtiEl("outcomeDeclaration", "identifier", "SCORE", "cardinality", "single", "baseType", "float",
+			"normalMaximum", strconv.FormatUint(uint64(points), 10)).
+			add(qtiEl("defaultValue").add(qtiEl("value").add(qtiText("0")))),
+	}
+	body := qtiEl("itemBody")
+	processing := qtiEl("responseProcessing")
+	feedback := make([]*qtiNodeSt, 0)
+
+	stem := func() {
+		image := pkg.image(q.Image)
+		if image != nil && q.Image.above() {
+			body.add(qtiEl("p").add(image))
+		}
+		body.add(qtiParagraphs(q.Question.string)...)
+		if image != nil && !q.Image.above() {
+			body.add(qtiEl("p").add(image))
+		}
+	}
+	choices := func(texts []string, correct []string, selectAll bool, penalty float64) {
+		cardinality := ternary(selectAll, "multiple", "single")
+		decls = append(decls, qtiResponse("RESPONSE", cardinality, "identifier", correct...))
+		interaction := qtiEl("choiceInteraction", "responseIdentifier", "RESPONSE",
+			"shuffle", strconv.FormatBool(!q.KeepChoices), "maxChoices", ternary(selectAll, "0", "1"))
+		hasFeedback := false
+		for ci, text := range texts {
+			choiceID := fmt.Sprintf("C%d", ci+1)
+			interaction.add(qtiEl("simpleChoice", "identifier", choiceID).
+				add(qtiText(text), pkg.image(q.choiceImage(ci))))
+			if text := q.choiceFeedback(ci); text != "" {
+				hasFeedback = true
+				feedback = append(feedback, qtiEl("modalFeedback", "outcomeIdentifier", "FEEDBACK",
+					"identifier", choiceID, "showHide", "show").add(qtiText(text)))
+			}
+		}
+		stem()
+		body.add(interaction)
+
+		matched := qtiEl("match").add(qtiEl("variable", "identifier", "RESPONSE"),
+			qtiEl("correct", "identifier", "RESPONSE"))
+		if penalty > 0 {
+			// A blank answer costs nothing.
+			processing.add(qtiEl("responseCondition").add(
+				qtiEl("responseIf").add(qtiEl("isNull").add(qtiEl("variable", "identifier", "RESPONSE")),
+					qtiScore(qtiFloat(0))),
+				qtiEl("responseElseIf").add(matched, qtiScore(qtiFloat(float64(points)))),
+				qtiEl("responseElse").add(qtiScore(qtiFloat(-penalty)))))
+		} else {
+			processing.add(qtiCondition(matched, points))
+		}
+		if hasFeedback {
+			outcomes = append(outcomes, qtiEl("outcomeDeclaration", "identifier", "FEEDBACK",
+				"cardinality", cardinality, "baseType", "identifier"))
+			processing.add(qtiEl("setOutcomeValue", "identifier", "FEEDBACK").
+				add(qtiEl("variable", "identifier", "RESPONSE")))
+		}
+	}
+
+	switch {
+	case q.Formula != "":
+		return nil, fmt.Errorf("has parameters")
+	case len(q.Callouts) != 0 || q.Items.fixMissing().Size() != 0:
+		return nil, fmt.Errorf("has no QTI item type")
+	case head.Type == "true-false":
+		choices([]string{"True", "False"}, []string{ternary(q.True, "C1", "C2")}, false,
+			head.NegativeMarking*float64(points))
+	case q.Numeric != nil || q.Value != nil:
+		answer := NumericAnswerSt{}
+		if q.Numeric != nil {
+			answer = *q.Numeric
+		} else {
+			answer, _ = q.numericAnswer(ToleranceSt{}, "")
+		}
+		decls = append(decls, qtiResponse("RESPONSE", "single", "float", numberString(answer.Value)))
+		stem()
+		body.add(qtiEl("p").add(qtiEl("textEntryInteraction", "responseIdentifier", "RESPONSE"),
+			ternary(answer.Units != "", qtiText(" "+answer.Units), nil)))
+		equal := qtiEl("equal", "toleranceMode", "exact")
+		if answer.Tolerance.Amount != 0 {
+			equal = qtiEl("equal", "toleranceMode", ternary(answer.Tolerance.Percent, "relative", "absolute"),
+				"tolerance", numberString(answer.Tolerance.Amount))
+		}
+		processing.add(qtiCondition(equal.add(qtiEl("variable", "identifier", "RESPONSE"),
+			qtiEl("correct", "identifier", "RESPONSE")), points))
+	case strings.Contains(q.Question.string, fillinMarker):
+		worth := *q
+		worth.Points = points
+		sum := qtiEl("sum")
+		blank := 0
+		if image := pkg.image(q.Image); image != nil {
+			body.add(qtiEl("p").add(image))
+		}
+		for _, line := range strings.Split(strings.TrimSpace(q.Question.string), "\n") {
+			paragraph := qtiEl("p")
+			for pi, part := range strings.Split(line, fillinMarker) {
+				if pi != 0 {
+					blank++
+					responseID := fmt.Sprintf("RESPONSE_%d", blank)
+					answer, _ := q.Answers.fixMissing().Get(blank - 1)
+					decls = append(decls, qtiResponse(responseID, "single", "string", answer).
+						add(qtiMapping([]string{answer}, float64(worth.blankPoints(blank-1)))))
+					paragraph.add(qtiEl("textEntryInteraction", "responseIdentifier", responseID))
+					sum.add(qtiEl("mapResponse", "identifier", responseID))
+				}
+				if part != "" {
+					paragraph.add(qtiText(part))
+				}
+			}
+			body.add(paragraph)
+		}
+		processing.add(qtiScore(sum))
+	case q.Choices.fixMissing().Size() != 0:
+		correct := make([]string, 0)
+		q.Choices.Each(
+			func(ci int, _ string) {
+				if q.isCorrect(ci) {
+					correct = append(correct, fmt.Sprintf("C%d", ci+1))
+				}
+			},
+		)
+		choices(q.Choices.Values(), correct, q.selectAll(),
+			ternary(q.selectAll(), 0, head.NegativeMarking*float64(points)))
+	case q.Answers.fixMissing().Size() != 0 && head.Type != "essay":
+		decls = append(decls, qtiResponse("RESPONSE", "single", "string", q.Answers.Values()[0]).
+			add(qtiMapping(q.Answers.Values(), float64(points))))
+		stem()
+		length := 10
+		for _, answer := range q.Answers.Values() {
+			length = genfuncs.Max(length, len(answer))
+		}
+		body.add(qtiEl("p").add(qtiEl("textEntryInteraction", "responseIdentifier", "RESPONSE",
+			"expectedLength", strconv.Itoa(length))))
+		processing.add(qtiScore(qtiEl("mapResponse", "identifier", "RESPONSE")))
+	default:
+		decls = append(decls, qtiResponse("RESPONSE", "single", "string"))
+		stem()
+		if q.Answers.fixMissing().Size() != 0 {
+			body.add(qtiEl("rubricBlock", "view", "scorer").
+				add(qtiParagraphs(strings.Join(q.Answers.Values(), "\n"))...))
+		}
+		body.add(qtiEl("extendedTextInteraction", "responseIdentifier", "RESPONSE"))
+		processing = nil
+	}
+
+	item := qtiEl("assessmentItem", append(slices.Clone(qtiNamespace), "identifier", id, "title", title,
+		"adaptive", "false", "timeDependent", "false")...)
+	item.add(decls...)
+	item.add(outcomes...)
+	item.add(body, processing)
+	return item.add(feedback...), nil
+}
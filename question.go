@@ -3,33 +3,210 @@ package testparts
 import (
 	"encoding/json"
 	"fmt"
+	"log"
+	"math/rand"
+	"slices"
 	"strings"
 
 	aiken "github.com/aldinokemal/go-aiken"
 	"github.com/daichi-m/go18ds/lists/arraylist"
 	"github.com/daichi-m/go18ds/sets/linkedhashset"
 	"github.com/nwillc/genfuncs"
-	"muzzammil.xyz/jsonc"
 )
 
 func (m *MultipleChoiceSt) Init(section JSONSectionSt, numTest uint) {
 	m.SectionHeadSt = getSectionHead(section)
 	m.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
-		section.NumCol, section.KeepOrder)
+		section.NumCol, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
 	m.AllQuestions = section.Questions
 }
 
 func (r *ReadingCompSt) Init(section JSONSectionSt, numTest uint) {
 	r.SectionHeadSt = getSectionHead(section)
 	r.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
-		section.NumCol, section.KeepOrder)
+		section.NumCol, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
 	r.AllQuestions = section.Questions
 }
 
+func (s *StimulusGroupsSt) Init(section JSONSectionSt, numTest uint) {
+	s.SectionHeadSt = getSectionHead(section)
+	s.AllStimuli = section.Stimuli
+	s.AllQuestions = QuestionSetSt{List: arraylist.New[*QuestionsSt]()}
+	for _, stimulus := range s.AllStimuli {
+		stimulus.Image.resolve(section.AssetDir)
+		stimulus.Questions.fixMissing()
+		stimulus.Questions.Each(
+			func(_ int, q *QuestionsSt) {
+				if q.Required {
+					q.Used = numTest + 1
+				}
+				q.resolveImages(section.AssetDir)
+				s.AllQuestions.Add(q)
+			},
+		)
+	}
+
+	numGroups := ternary(section.GroupsOnTest != 0, section.GroupsOnTest, uint(len(s.AllStimuli)))
+	s.Groups = make([][]StimulusGroupSt, 0, numTest)
+	s.Questions = QuestionListSt{arraylist.New[QuestionSetSt]()}
+	for student := uint(0); student < numTest; student++ {
+		rnd := studentRand(section.Seed, student)
+		groups := make([]StimulusGroupSt, 0, numGroups)
+		questions := QuestionSetSt{List: arraylist.New[*QuestionsSt]()}
+		for _, stimulus := range stimulusSet(s.AllStimuli, numGroups, numTest, section.KeepOrder, rnd) {
+			group := stimulus.group(numTest, section.NumCol, section.KeepOrder, rnd)
+			groups = append(groups, group)
+			questions.Add(group.Questions.Values()...)
+		}
+		questions.allocatePoints(section.Points)
+		s.Groups = append(s.Groups, groups)
+		s.Questions.Add(questions)
+	}
+}
+
+func (t *TrueFalseSt) Init(section JSONSectionSt, numTest uint) {
+	t.SectionHeadSt = getSectionHead(section)
+	if t.Instructions == "" {
+		t.Instructions = ternary(t.CorrectFalse,
+			"Circle T if the statement is true and F if it is false. Rewrite each false statement to make it true.",
+			"Circle T if the statement is true and F if it is false.")
+	}
+	section.Questions.Each(
+		func(_ int, q *QuestionsSt) {
+			q.makeTrueFalse()
+		},
+	)
+	t.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
+		section.NumCol, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
+	t.AllQuestions = section.Questions
+}
+
+// makeTrueFalse turns a statement into a question choosing True or False,
+// in that order.
+func (q *QuestionsSt) makeTrueFalse() {
+	q.Choices = WordsSt{List: arraylist.New("True", "False")}
+	q.Answer = ternary[uint](q.True, 1, 2)
+	q.KeepChoices = true
+}
+
+func (n *NumericSt) Init(section JSONSectionSt, numTest uint) {
+	n.SectionHeadSt = getSectionHead(section)
+	n.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
+		section.NumCol, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
+	n.Questions.Each(
+		func(_ int, questions QuestionSetSt) {
+			questions.Each(
+				func(_ int, q *QuestionsSt) {
+					answer, err := q.numericAnswer(section.Tolerance, section.Units)
+					if err != nil {
+						log.Printf("Unable to work out the answer to %q: %v\n", q.Question.CleanString(), err)
+						return
+					}
+					q.Numeric = &answer
+					q.Answers = WordsSt{List: arraylist.New(answer.String())}
+				},
+			)
+		},
+	)
+	n.AllQuestions = section.Questions
+	n.AllQuestions.Each(
+		func(_ int, q *QuestionsSt) {
+			if answer, err := q.numericAnswer(section.Tolerance, section.Units); err == nil && q.Formula == "" {
+				q.Numeric = &answer
+			}
+		},
+	)
+}
+
+func (o *OrderingSt) Init(section JSONSectionSt, numTest uint) {
+	o.SectionHeadSt = getSectionHead(section)
+	if o.Instructions == "" {
+		o.Instructions = "Write the letters of the items in the right order."
+	}
+	if o.FormInstructions == "" {
+		o.FormInstructions = "Mark the place of each item in the right order."
+	}
+	section.Questions.Each(
+		func(_ int, q *QuestionsSt) {
+			q.makeOrdering()
+		},
+	)
+	o.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
+		1, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
+	o.Questions.Each(
+		func(_ int, questions QuestionSetSt) {
+			questions.Each(
+				func(_ int, q *QuestionsSt) {
+					q.Answers = WordsSt{List: arraylist.New(strings.Join(q.orderLetters(), ", "))}
+				},
+			)
+		},
+	)
+	o.AllQuestions = section.Questions
+}
+
+func (c *ClozeSt) Init(section JSONSectionSt, numTest uint) {
+	c.SectionHeadSt = getSectionHead(section)
+	if c.Instructions == "" {
+		c.Instructions = ternary(section.WordBank,
+			"Fill in each numbered blank with a word from the word bank.",
+			"Fill in each numbered blank.")
+	}
+	section.Questions.Each(
+		func(_ int, q *QuestionsSt) {
+			q.makeCloze()
+		},
+	)
+	c.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
+		section.NumCol, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
+	c.AllQuestions = section.Questions
+	c.WordBank = StringListSt{arraylist.New[NLStringListSt]()}
+	if section.WordBank {
+		c.Questions.Each(
+			func(student int, questions QuestionSetSt) {
+				c.WordBank.Add(wordBank(questions, section.WordList, studentRand(section.Seed, uint(student))))
+			},
+		)
+	}
+}
+
+func (e *EssaySt) Init(section JSONSectionSt, numTest uint) {
+	e.SectionHeadSt = getSectionHead(section)
+	e.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
+		section.NumCol, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
+	e.AllQuestions = section.Questions
+}
+
+func (d *DiagramSt) Init(section JSONSectionSt, numTest uint) {
+	d.SectionHeadSt = getSectionHead(section)
+	if d.Instructions == "" {
+		d.Instructions = ternary(section.WordBank,
+			"Label each numbered part of the diagram with a word from the word bank.",
+			"Label each numbered part of the diagram.")
+	}
+	d.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
+		section.NumCol, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
+	d.AllQuestions = section.Questions
+	d.WordBank = StringListSt{arraylist.New[NLStringListSt]()}
+	d.Questions.Each(
+		func(student int, questions QuestionSetSt) {
+			rnd := studentRand(section.Seed, uint(student))
+			questions.Each(
+				func(_ int, q *QuestionsSt) {
+					q.placeCallouts(rnd, section.KeepOrder)
+				},
+			)
+			if section.WordBank {
+				d.WordBank.Add(wordBank(questions, section.WordList, rnd))
+			}
+		},
+	)
+}
+
 func (w *WordProblemSt) Init(section JSONSectionSt, numTest uint) {
 	w.SectionHeadSt = getSectionHead(section)
 	w.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
-		section.NumCol, section.KeepOrder)
+		section.NumCol, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
 	w.AllQuestions = section.Questions
 }
 
@@ -37,7 +214,7 @@ func (q *QuizSt) Init(section JSONSectionSt, numTest uint) {
 	q.SectionHeadSt = getSectionHead(section)
 	q.Quiz = true
 	q.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
-		section.NumCol, section.KeepOrder)
+		section.NumCol, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
 	q.AllQuestions = section.Questions
 }
 
@@ -46,6 +223,9 @@ func (w *WordMatchSt) Init(section JSONSectionSt, numTest uint) {
 	if w.ColumnHead.Empty() {
 		w.ColumnHead.Add("Word", "Definition")
 	}
+	if section.Words.sharesDefinitions() {
+		w.Instructions = strings.TrimSpace(w.Instructions + " Options may be used more than once.")
+	}
 	w.List = arraylist.New[WordListSt]()
 	w.WordDist = WordDistMapSt{Map: newWordDistMap()}
 	w.AllWords = &section.Words
@@ -57,31 +237,27 @@ func (w *WordMatchSt) Init(section JSONSectionSt, numTest uint) {
 	)
 
 	for w.Size() < int(numTest) {
+		rnd := studentRand(section.Seed, uint(w.Size()))
 		wList := WordListSt{
 			List: arraylist.New[*WordDefSt](),
 		}
 
-		w.WordDist.WordSet(section.NumQuest, numTest).Each(
+		w.WordDist.WordSet(section.NumQuest, numTest, rnd).Each(
 			func(_ int, word NLStringSt) {
 				wList.Add(&WordDefSt{Word: word})
 			},
 		)
 
-		defines := make([]NLStringSt, 0)
 		wList.Each(
 			func(_ int, wd *WordDefSt) {
-				defines = append(defines, section.Words.get(wd.Word))
+				wd.Def = section.Words.get(wd.Word)
 			},
 		)
-		defines = shuffleSlice(defines)
+		options := shuffleSlice(rnd, w.matchOptions(section, wList, rnd))
+		wList.Options = NLStringListSt{List: arraylist.New(options...)}
 		wList.Each(
-			func(i int, wd *WordDefSt) {
-				wd.Def = defines[i]
-				answer, _ := arraylist.New(defines...).Find(
-					func(_ int, value NLStringSt) bool {
-						return value == section.Words.get(wd.Word)
-					},
-				)
+			func(_ int, wd *WordDefSt) {
+				answer := wList.Options.IndexOf(wd.Def)
 				wd.Answer = NLStringSt{fmt.Sprintf("%c", 'A'+answer)}
 			},
 		)
@@ -89,14 +265,64 @@ func (w *WordMatchSt) Init(section JSONSectionSt, numTest uint) {
 	}
 }
 
+// matchOptions lists the right column of a copy: the definitions of its
+// words, once each, the section's distractors and extraDefinitions
+// definitions of words not on the copy.
+func (w *WordMatchSt) matchOptions(section JSONSectionSt, wList WordListSt, rnd *rand.Rand) []NLStringSt {
+	options := linkedhashset.New[NLStringSt]()
+	onCopy := linkedhashset.New[NLStringSt]()
+	wList.Each(
+		func(_ int, wd *WordDefSt) {
+			options.Add(wd.Def)
+			onCopy.Add(wd.Word)
+		},
+	)
+	for _, distractor := range section.Distractors.fixMissing().Values() {
+		options.Add(NLStringSt{distractor})
+	}
+
+	if section.ExtraDefinitions == 0 {
+		return options.Values()
+	}
+	limit := options.Size() + int(section.ExtraDefinitions)
+	others := make([]NLStringSt, 0)
+	section.Words.Each(
+		func(word, def NLStringSt) {
+			if !onCopy.Contains(word) && !options.Contains(def) {
+				others = append(others, def)
+			}
+		},
+	)
+	for _, def := range shuffleSlice(rnd, others) {
+		if options.Size() == limit {
+			break
+		}
+		options.Add(def)
+	}
+	return options.Values()
+}
+
+// sharesDefinitions reports whether two words have the same definition, so
+// an option can be the answer more than once.
+func (wdm *WordDefMapSt) sharesDefinitions() bool {
+	defs := linkedhashset.New[NLStringSt]()
+	wdm.Each(
+		func(_, def NLStringSt) {
+			defs.Add(def)
+		},
+	)
+	return defs.Size() != wdm.Size()
+}
+
 func (w *WordMatchSt) ToQuestions() error {
 	w.AllQuestions = QuestionSetSt{List: arraylist.New[*QuestionsSt]()}
 	words := NLStringListSt{List: arraylist.New[NLStringSt](w.AllWords.Keys()...)}
+	rnd := newRand(w.Seed)
 
 	w.AllWords.Each(func(word, def NLStringSt) {
 		choice := linkedhashset.New[string](word.string)
 		for choice.Size() < 4 {
-			choice.Add(words.getRandom())
+			choice.Add(words.getRandom(rnd))
 		}
 
 		w.AllQuestions.Add(
@@ -114,10 +340,12 @@ func (p *PassageCompletionSt) Init(section JSONSectionSt, numTest uint) {
 	p.SectionHeadSt = getSectionHead(section)
 	p.WordList = StringListSt{arraylist.New[NLStringListSt]()}
 	p.Answers = StringListSt{arraylist.New[NLStringListSt]()}
+	p.Questions = QuestionListSt{arraylist.New[QuestionSetSt]()}
 
 	for i := 0; i < int(numTest); i++ {
+		rnd := studentRand(section.Seed, uint(i))
 		words := arraylist.New[NLStringSt]()
-		for _, w := range shuffleSlice(section.WordList.Values()) {
+		for _, w := range shuffleSlice(rnd, section.WordList.Values()) {
 			words.Add(NLStringSt{w})
 		}
 
@@ -135,27 +363,45 @@ func (p *PassageCompletionSt) Init(section JSONSectionSt, numTest uint) {
 			},
 		)
 		p.Answers.Insert(i, answers)
+		p.Questions.Insert(i, p.blankQuestions(p.WordList.get(i), section.WordList.Values()))
+	}
+
+	// The stored blanks draw their own word order, past every copy's, and
+	// let the quiz and exports shuffle it again, so no blank's answer sits
+	// at its own number.
+	words := NLStringListSt{List: arraylist.New[NLStringSt]()}
+	for _, w := range shuffleSlice(studentRand(section.Seed, numTest), section.WordList.Values()) {
+		words.Add(NLStringSt{w})
 	}
+	p.AllQuestions = p.blankQuestions(words, section.WordList.Values())
+	p.AllQuestions.Each(
+		func(_ int, q *QuestionsSt) {
+			q.Used, q.KeepChoices = numTest, false
+		},
+	)
 }
 
 func (c *CompQuestionsSt) Init(section JSONSectionSt, numTest uint) {
 	c.SectionHeadSt = getSectionHead(section)
 	c.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
-		section.NumCol, section.KeepOrder)
+		section.NumCol, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
 	c.AllQuestions = section.Questions
 }
 
 func (c *CustomSt) Init(section JSONSectionSt, numTest uint) {
 	c.SectionHeadSt = getSectionHead(section)
 	c.Questions = section.Questions.GetQuestions(numTest, section.NumQuest,
-		section.NumCol, section.KeepOrder)
+		section.NumCol, section.Points, section.KeepOrder, section.Blueprint, section.Seed)
 	c.AllQuestions = section.Questions
 	c.Answers = section.Answers.List
 	c.AnswerText = section.AnswerText.List
 }
 
-func (questions QuestionSetSt) GetQuestions(numTest, numQuest, numCol uint,
-	keepOrder bool) QuestionListSt {
+// GetQuestions picks each student's questions, following the blueprint if
+// there is one, shuffles their choices and shares out the section's points,
+// using the random source studentRand derives from seed.
+func (questions QuestionSetSt) GetQuestions(numTest, numQuest, numCol, points uint,
+	keepOrder bool, blueprint BlueprintSt, seed int64) QuestionListSt {
 	newQuestions := QuestionListSt{
 		List: arraylist.New[QuestionSetSt](),
 	}
@@ -163,41 +409,103 @@ func (questions QuestionSetSt) GetQuestions(numTest, numQuest, numCol uint,
 		numQuest = uint(questions.Size())
 	}
 	for newQuestions.Size() < int(numTest) {
+		rnd := studentRand(seed, uint(newQuestions.Size()))
 		newQuests := QuestionSetSt{
 			List: arraylist.New[*QuestionsSt](),
 		}
-		questions.QuestionSet(numQuest, numTest, keepOrder).Each(
+		questSet := QuestionSetSt{}
+		if len(blueprint) != 0 {
+			questSet = questions.BlueprintSet(blueprint, numQuest, keepOrder, rnd)
+		} else {
+			questSet = questions.QuestionSet(numQuest, numTest, keepOrder, rnd)
+		}
+		questSet.Each(
 			func(_ int, q *QuestionsSt) {
-				newQuest := &QuestionsSt{
-					NumCol:   ternary(q.NumCol != 0, q.NumCol, numCol),
-					Question: q.Question,
-					Parts:    q.Parts.fixMissing(),
-					Required: q.Required,
-					Choices:  q.Choices.fixMissing(),
-					Answers:  q.Answers.fixMissing(),
-				}
-				if newQuest.Choices.Size() != 0 {
-					answer, _ := newQuest.Choices.Get(int(q.Answer) - 1)
-					newQuest.Choices.List = arraylist.New(shuffleSlice(newQuest.Choices.Values())...)
-					newQuest.Choices.Each(
-						func(ci int, c string) {
-							if answer == c {
-								newQuest.Answers.List = arraylist.New(fmt.Sprintf("%c", 'A'+ci), c)
-							}
-						},
-					)
-				}
-				newQuests.Add(newQuest)
+				newQuests.Add(q.copyFor(numCol, rnd))
 			},
 		)
+		newQuests.allocatePoints(points)
 		newQuestions.Add(newQuests)
 	}
 
 	return newQuestions
 }
 
+// copyFor makes a student's copy of the question, filling in its parameters
+// and shuffling its choices with rnd.
+func (q *QuestionsSt) copyFor(numCol uint, rnd *rand.Rand) *QuestionsSt {
+	newQuest := &QuestionsSt{
+		NumCol:      ternary(q.NumCol != 0, q.NumCol, numCol),
+		Question:    q.Question,
+		Parts:       q.Parts.fixMissing(),
+		Required:    q.Required,
+		Points:      q.Points,
+		PartPoints:  q.PartPoints,
+		Difficulty:  q.Difficulty,
+		Tags:        q.Tags.fixMissing(),
+		Choices:     q.Choices.fixMissing(),
+		Answers:     q.Answers.fixMissing(),
+		Formula:     q.Formula,
+		Answer:      q.Answer,
+		Correct:     q.Correct,
+		Value:       q.Value,
+		Tolerance:   q.Tolerance,
+		Units:       q.Units,
+		True:        q.True,
+		Correction:  q.Correction,
+		KeepChoices: q.KeepChoices,
+		Ordering:    q.Ordering,
+		Rubric:      q.Rubric,
+		Callouts:    q.Callouts,
+		Image:       q.Image,
+		Feedback:    WordsSt{List: arraylist.New[string]()},
+	}
+	if q.isParameterized() {
+		if err := newQuest.instantiate(q, rnd); err != nil {
+			log.Printf("Unable to fill in question %q: %v\n", q.Question.CleanString(), err)
+		}
+	}
+	if newQuest.Choices.Size() != 0 {
+		choices := newQuest.Choices.Values()
+		order := sequenceUsing([]int{},
+			func(value int) int { return value }, 0, len(choices))
+		if !newQuest.KeepChoices {
+			order = shuffleSlice(rnd, order)
+		}
+		for newQuest.Ordering && len(order) > 1 && slices.IsSorted(order) {
+			order = shuffleSlice(rnd, order)
+		}
+		if newQuest.Ordering {
+			newQuest.Sequence = order
+		}
+		newQuest.Choices.List = arraylist.New[string]()
+		letters, answers, correct := "", []string{}, []uint{}
+		for ci, from := range order {
+			newQuest.Choices.Add(choices[from])
+			newQuest.ChoiceImages = append(newQuest.ChoiceImages, q.choiceImage(from))
+			if q.Feedback.fixMissing().Size() != 0 {
+				newQuest.Feedback.Add(q.choiceFeedback(from))
+			}
+			if newQuest.isCorrect(from) {
+				letters += fmt.Sprintf("%c", 'A'+ci)
+				answers = append(answers, choices[from])
+				correct = append(correct, uint(ci+1))
+			}
+		}
+		if len(correct) != 0 {
+			newQuest.Answers.List = arraylist.New(append([]string{letters}, answers...)...)
+		}
+		if newQuest.selectAll() {
+			newQuest.Correct = correct
+		} else if len(correct) != 0 {
+			newQuest.Answer = correct[0]
+		}
+	}
+	return newQuest
+}
+
 func (q QuestionSetSt) QuestionSet(numQuest, numTest uint,
-	keepOrder bool) QuestionSetSt {
+	keepOrder bool, rnd *rand.Rand) QuestionSetSt {
 	if keepOrder {
 		return QuestionSetSt{List: arraylist.New(q.Values()[:numQuest]...)}
 	}
@@ -219,7 +527,7 @@ func (q QuestionSetSt) QuestionSet(numQuest, numTest uint,
 
 		var winner *QuestionsSt
 		for found := true; found; found = newSet.Contains(winner) {
-			winner = shuffleSlice(arraylist.New(q.Values()...).Select(
+			winner = shuffleSlice(rnd, arraylist.New(q.Values()...).Select(
 				func(_ int, q *QuestionsSt) bool {
 					return q.Used == minUse
 				},
@@ -229,10 +537,10 @@ func (q QuestionSetSt) QuestionSet(numQuest, numTest uint,
 		winner.Used++
 	}
 
-	return QuestionSetSt{List: arraylist.New(shuffleSlice(newSet.Values())...)}
+	return QuestionSetSt{List: arraylist.New(shuffleSlice(rnd, newSet.Values())...)}
 }
 
-func (w WordDistMapSt) WordSet(numQuest, numTest uint) NLStringListSt {
+func (w WordDistMapSt) WordSet(numQuest, numTest uint, rnd *rand.Rand) NLStringListSt {
 	newSet := arraylist.New[NLStringSt]()
 	for newSet.Size() < int(numQuest) {
 		minUse := numTest
@@ -245,7 +553,7 @@ func (w WordDistMapSt) WordSet(numQuest, numTest uint) NLStringListSt {
 
 		var winner NLStringSt
 		for found := true; found; found = newSet.Contains(winner) {
-			winner = shuffleSlice(w.Select(
+			winner = shuffleSlice(rnd, w.Select(
 				func(w NLStringSt, use uint) bool {
 					return use == minUse
 				},
@@ -255,19 +563,24 @@ func (w WordDistMapSt) WordSet(numQuest, numTest uint) NLStringListSt {
 		newSet.Add(winner)
 	}
 
-	return NLStringListSt{List: arraylist.New(shuffleSlice(newSet.Values())...)}
+	return NLStringListSt{List: arraylist.New(shuffleSlice(rnd, newSet.Values())...)}
 }
 
 func ProcessInclude(section JSONSectionSt, assetdir string) {
 	if section.Type == "word-match" {
 		ProcessWordsInclude(section, assetdir)
+		ProcessIncludeGift(section, assetdir)
+		ProcessIncludeMoodle(section, assetdir)
+		ProcessIncludeQTI(section, assetdir)
+		ProcessIncludeMarkdown(section, assetdir)
+		ProcessIncludeCsv(section, assetdir)
 		return
 	}
 
 	section.Include.Each(
 		func(_ int, inc string) {
 			filePath := assetdir + "/" + inc
-			_, bytes, err := jsonc.ReadFromFile(filePath)
+			spec, err := loadSpecFile(filePath)
 
 			if err != nil {
 				fmt.Printf("Unable to load include file %s\n", filePath)
@@ -275,7 +588,7 @@ func ProcessInclude(section JSONSectionSt, assetdir string) {
 			}
 
 			incJSON := make([]*QuestionsSt, 0)
-			if err := json.Unmarshal(bytes, &incJSON); err != nil {
+			if err := json.Unmarshal(spec.questionPool().data, &incJSON); err != nil {
 				fmt.Printf("ProcessInclude Unable to parse include file %s: %v\n",
 					filePath, err)
 				return
@@ -287,13 +600,18 @@ func ProcessInclude(section JSONSectionSt, assetdir string) {
 
 	ProcessIncludeQuestgen(section, assetdir)
 	ProcessIncludeAiken(section, assetdir)
+	ProcessIncludeGift(section, assetdir)
+	ProcessIncludeMoodle(section, assetdir)
+	ProcessIncludeQTI(section, assetdir)
+	ProcessIncludeMarkdown(section, assetdir)
+	ProcessIncludeCsv(section, assetdir)
 }
 
 func ProcessIncludeQuestgen(section JSONSectionSt, assetdir string) {
 	section.IncludeQuestgen.Each(
 		func(_ int, inc string) {
 			filePath := assetdir + "/" + inc
-			_, bytes, err := jsonc.ReadFromFile(filePath)
+			spec, err := loadSpecFile(filePath)
 
 			if err != nil {
 				fmt.Printf("Unable to load Questgen include file %s\n", filePath)
@@ -301,7 +619,7 @@ func ProcessIncludeQuestgen(section JSONSectionSt, assetdir string) {
 			}
 
 			incQuestgenJSON := make([]*QuestgenQuestionSt, 0)
-			if err := json.Unmarshal(bytes, &incQuestgenJSON); err != nil {
+			if err := json.Unmarshal(spec.data, &incQuestgenJSON); err != nil {
 				fmt.Printf("ProcessInclude Unable to parse Questgen include file %s: %v\n",
 					filePath, err)
 				return
@@ -352,7 +670,7 @@ func ProcessWordsInclude(section JSONSectionSt, assetdir string) {
 	section.Include.Each(
 		func(_ int, inc string) {
 			filePath := assetdir + "/" + inc
-			_, bytes, err := jsonc.ReadFromFile(filePath)
+			spec, err := loadSpecFile(filePath)
 
 			if err != nil {
 				fmt.Printf("Unable to load include file %s\n", filePath)
@@ -360,7 +678,7 @@ func ProcessWordsInclude(section JSONSectionSt, assetdir string) {
 			}
 
 			incJSON := new(WordDefMapSt)
-			if err := incJSON.UnmarshalJSON(bytes); err != nil {
+			if err := incJSON.UnmarshalJSON(spec.data); err != nil {
 				fmt.Printf("ProcessWordsInclude Unable to parse include file %s: %v\n",
 					filePath, err)
 				return
@@ -382,19 +700,27 @@ func getSectionHead(section JSONSectionSt) SectionHeadSt {
 		Title:            section.Title,
 		NumLines:         section.NumLines,
 		Points:           section.Points,
+		QuestionPoints:   section.Questions.hasFixedPoints(),
+		NegativeMarking:  section.NegativeMarking,
 		NumCol:           section.NumCol,
 		NumQuest:         section.NumQuest,
 		AnswerLines:      section.AnswerLines,
 		QuizBox:          section.QuizBox,
 		KeepOrder:        section.KeepOrder,
+		CorrectFalse:     section.CorrectFalse,
 		Instructions:     section.Instructions.string,
 		FormInstructions: section.FormInstructions.string,
 		Text:             section.Text.string,
 		ColumnHead:       section.ColumnHead,
+		Seed:             section.Seed,
 		Quiz:             false,
 	}
 }
 
+func (s *StimulusGroupsSt) GetHead() *SectionHeadSt {
+	return &s.SectionHeadSt
+}
+
 func (r *ReadingCompSt) GetHead() *SectionHeadSt {
 	return &r.SectionHeadSt
 }
@@ -403,6 +729,30 @@ func (m *MultipleChoiceSt) GetHead() *SectionHeadSt {
 	return &m.SectionHeadSt
 }
 
+func (t *TrueFalseSt) GetHead() *SectionHeadSt {
+	return &t.SectionHeadSt
+}
+
+func (n *NumericSt) GetHead() *SectionHeadSt {
+	return &n.SectionHeadSt
+}
+
+func (o *OrderingSt) GetHead() *SectionHeadSt {
+	return &o.SectionHeadSt
+}
+
+func (c *ClozeSt) GetHead() *SectionHeadSt {
+	return &c.SectionHeadSt
+}
+
+func (e *EssaySt) GetHead() *SectionHeadSt {
+	return &e.SectionHeadSt
+}
+
+func (d *DiagramSt) GetHead() *SectionHeadSt {
+	return &d.SectionHeadSt
+}
+
 func (w *WordProblemSt) GetHead() *SectionHeadSt {
 	return &w.SectionHeadSt
 }
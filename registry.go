@@ -0,0 +1,125 @@
+package testparts
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/daichi-m/go18ds/maps/treemap"
+)
+
+// Section types are made by the factories registered under their names, the
+// "type" of a section in a test file. A program using this package can
+// register its own types alongside the built-in ones, saying which outputs
+// they support; sections are left out of the others.
+
+// SectionOutputSt is a set of outputs a section type supports.
+type SectionOutputSt uint
+
+const (
+	OutputLatex SectionOutputSt = 1 << iota
+	OutputRTF
+	OutputForms
+	OutputDB
+	OutputDistrib
+
+	OutputAll = OutputLatex | OutputRTF | OutputForms | OutputDB | OutputDistrib
+)
+
+// SectionFactory makes an empty section of a type, ready for Init.
+type SectionFactory func() SectionSt
+
+// DBSectionSt is a section that can be stored in the database, as required
+// of types supporting OutputDB.
+type DBSectionSt interface {
+	SectionSt
+	DBQuestions() QuestionSetSt
+}
+
+type sectionTypeSt struct {
+	factory SectionFactory
+	outputs SectionOutputSt
+}
+
+var sectionTypes = struct {
+	sync.RWMutex
+	*treemap.Map[string, *sectionTypeSt]
+}{Map: treemap.NewWithStringComparator[*sectionTypeSt]()}
+
+func init() {
+	builtins := []struct {
+		name    string
+		factory SectionFactory
+		outputs SectionOutputSt
+	}{
+		{"word-match", func() SectionSt { return new(WordMatchSt) }, OutputAll &^ OutputDistrib},
+		{"multiple-choice", func() SectionSt { return new(MultipleChoiceSt) }, OutputAll},
+		{"true-false", func() SectionSt { return new(TrueFalseSt) }, OutputAll},
+		{"numeric", func() SectionSt { return new(NumericSt) }, OutputAll &^ OutputDB},
+		{"ordering", func() SectionSt { return new(OrderingSt) }, OutputAll &^ OutputDB},
+		{"cloze", func() SectionSt { return new(ClozeSt) }, OutputAll &^ OutputDB},
+		{"essay", func() SectionSt { return new(EssaySt) }, OutputAll},
+		{"diagram", func() SectionSt { return new(DiagramSt) }, OutputAll &^ OutputDB},
+		{"word-problem", func() SectionSt { return new(WordProblemSt) }, OutputAll &^ OutputDB},
+		{"quiz", func() SectionSt { return new(QuizSt) }, OutputAll &^ OutputDB},
+		{"stimulus-groups", func() SectionSt { return new(StimulusGroupsSt) }, OutputAll &^ OutputDB},
+		{"reading-comprehension", func() SectionSt { return new(ReadingCompSt) }, OutputAll &^ OutputDB},
+		{"comprehension-questions", func() SectionSt { return new(CompQuestionsSt) }, OutputAll &^ OutputDB},
+		{"passage-completion", func() SectionSt { return new(PassageCompletionSt) }, OutputAll},
+		{"custom", func() SectionSt { return new(CustomSt) }, OutputLatex | OutputRTF},
+	}
+	for _, builtin := range builtins {
+		if err := RegisterSectionType(builtin.name, builtin.factory, builtin.outputs); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterSectionType makes sections of type name with factory. A type can
+// only be registered once, and one supporting OutputDB must make
+// DBSectionSt sections.
+func RegisterSectionType(name string, factory SectionFactory, outputs SectionOutputSt) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf("a section type needs a name and a factory")
+	}
+	if _, isDB := factory().(DBSectionSt); outputs&OutputDB != 0 && !isDB {
+		return fmt.Errorf("section type %s supports the database but its sections have no DBQuestions", name)
+	}
+
+	sectionTypes.Lock()
+	defer sectionTypes.Unlock()
+	if _, found := sectionTypes.Get(name); found {
+		return fmt.Errorf("section type %s is already registered", name)
+	}
+	sectionTypes.Put(name, &sectionTypeSt{factory: factory, outputs: outputs})
+	return nil
+}
+
+// SectionTypes returns the names of the registered section types, sorted.
+func SectionTypes() []string {
+	sectionTypes.RLock()
+	defer sectionTypes.RUnlock()
+	return sectionTypes.Keys()
+}
+
+func lookupSectionType(name string) (*sectionTypeSt, bool) {
+	sectionTypes.RLock()
+	defer sectionTypes.RUnlock()
+	return sectionTypes.Get(name)
+}
+
+// supports reports whether the type of section supports output.
+func supports(section SectionSt, output SectionOutputSt) bool {
+	sectionType, found := lookupSectionType(section.GetHead().Type)
+	return found && sectionType.outputs&output != 0
+}
+
+// sectionsFor returns the sections whose types support output.
+func sectionsFor(sections []SectionSt, output SectionOutputSt) []SectionSt {
+	supported := make([]SectionSt, 0, len(sections))
+	for _, section := range sections {
+		if supports(section, output) {
+			supported = append(supported, section)
+		}
+	}
+	return supported
+}
@@ -67,6 +67,22 @@ func (pic *Picture) SetWidth(width int) *Picture {
 	pic.width = width
 	return pic
 }
+
+// GetWidth returns width in pixels
+func (pic *Picture) GetWidth() int {
+	return pic.width
+}
+
+// SetWidthKeepRatio sets width in pixels and scales height to match
+func (pic *Picture) SetWidthKeepRatio(width int) *Picture {
+	oldWidth := pic.width
+	pic.SetWidth(width)
+	if oldWidth != 0 {
+		pic.height = pic.height * pic.width / oldWidth
+	}
+	return pic
+}
+
 func (pic *Picture) SetHeight(height int) *Picture {
 	pic.height = height
 	return pic
@@ -14,8 +14,11 @@ func convertNonASCIIToUTF16(text string) string {
 	for _, r := range text {
 		// if isCyrillicLetter(r) {
 		switch {
-		case unicode.Is(unicode.Cyrillic, r) || r == '№' || r == '°':
-			res.WriteString(fmt.Sprintf("\\u%d\\'3f", utf16.Encode([]rune{r})[0]))
+		case r > unicode.MaxASCII:
+			// \u takes a signed 16-bit value, one per UTF-16 unit
+			for _, unit := range utf16.Encode([]rune{r}) {
+				res.WriteString(fmt.Sprintf("\\u%d\\'3f", int16(unit)))
+			}
 		default:
 			res.WriteString(string(r))
 		}
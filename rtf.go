@@ -2,14 +2,19 @@ package testparts
 
 import (
 	"fmt"
+	"log"
+	"os"
+	"strings"
 
 	rtfdoc "github.com/abaskin/testparts/rtf-doc"
+	"github.com/daichi-m/go18ds/lists/arraylist"
 
 	"github.com/chonla/roman-number-go"
 )
 
 type RTFDoc struct {
 	*rtfdoc.Document
+	Math *MathRendererSt
 }
 
 const tableWidth = 10000
@@ -162,7 +167,7 @@ func (doc *RTFDoc) sectionHeader(head *SectionHeadSt, num int) {
 	doc.AddParagraph().
 		SetAlign(rtfdoc.AlignLeft).
 		AddNewLine().
-		AddText(head.Instructions, 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+		AddText(head.instructions(), 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
 }
 
 func (m *MultipleChoiceSt) TestRTF(doc *RTFDoc, student uint, qNum *QuestNumSt) {
@@ -176,6 +181,142 @@ func (r *ReadingCompSt) TestRTF(doc *RTFDoc, student uint, qNum *QuestNumSt) {
 	rtfQuestions(doc, questions, &r.SectionHeadSt, qNum)
 }
 
+func (s *StimulusGroupsSt) TestRTF(doc *RTFDoc, student uint, qNum *QuestNumSt) {
+	for _, group := range s.Groups[student] {
+		doc.addStimulusRTF(group.StimulusSt)
+		rtfQuestions(doc, group.Questions, &s.SectionHeadSt, qNum)
+	}
+}
+
+func (t *TrueFalseSt) TestRTF(doc *RTFDoc, student uint, qNum *QuestNumSt) {
+	table := doc.AddTable().
+		SetWidth(tableWidth).
+		SetMarginLeft(50).
+		SetMarginRight(50).
+		SetMarginTop(50).
+		SetMarginBottom(50).
+		SetBorderColor(rtfdoc.ColorWhite)
+	cWidth := table.GetTableCellWidthByRatio(1, 7)
+
+	questions, _ := t.Questions.Get(int(student))
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			tr := table.AddTableRow()
+			tr.AddDataCell(cWidth[0]).
+				AddParagraph().
+				AddText("T     F", 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack).SetBold()
+			question := fmt.Sprintf("%d. %s", qNum.NextNumber(), q.Question.string)
+			if t.QuestionPoints {
+				question += " " + pointsText(q.Points)
+			}
+			doc.addText(tr.AddDataCell(cWidth[1]).AddParagraph(), question)
+			if t.CorrectFalse {
+				tr := table.AddTableRow()
+				tr.AddDataCell(cWidth[0]).AddParagraph().
+					AddText(" ", 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorWhite)
+				tr.AddDataCell(cWidth[1]).AddParagraph().
+					AddText(strings.Repeat("_", 60), 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+			}
+		},
+	)
+	p := doc.AddParagraph()
+	p.AddText(" ", 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorWhite)
+}
+
+func (n *NumericSt) TestRTF(doc *RTFDoc, student uint, qNum *QuestNumSt) {
+	questions, _ := n.Questions.Get(int(student))
+	rtfQuestions(doc, questions, &n.SectionHeadSt, qNum)
+}
+
+func (o *OrderingSt) TestRTF(doc *RTFDoc, student uint, qNum *QuestNumSt) {
+	questions, _ := o.Questions.Get(int(student))
+	rtfQuestions(doc, questions, &o.SectionHeadSt, qNum)
+}
+
+func (c *ClozeSt) TestRTF(doc *RTFDoc, student uint, qNum *QuestNumSt) {
+	t := doc.AddTable().
+		SetWidth(tableWidth).
+		SetMarginLeft(50).
+		SetMarginRight(50).
+		SetMarginTop(50).
+		SetMarginBottom(50).
+		SetBorderColor(rtfdoc.ColorWhite)
+
+	if words := c.WordBank.get(int(student)); words.Size() != 0 {
+		doc.addText(t.AddTableRow().AddDataCell(tableWidth).AddParagraph().SetAlign(rtfdoc.AlignCenter),
+			words.join("     "))
+	}
+
+	questions, _ := c.Questions.Get(int(student))
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			if q.Image.above() {
+				rtfImage(t, q.Image)
+			}
+			text := numberBlanks(q.Question.string, "(%d) ________", qNum)
+			if c.QuestionPoints {
+				text += " " + pointsText(q.Points)
+			}
+			doc.addText(t.AddTableRow().AddDataCell(tableWidth).AddParagraph(), text)
+			if q.Image != nil && !q.Image.above() {
+				rtfImage(t, q.Image)
+			}
+			t.AddTableRow().AddDataCell(tableWidth).AddParagraph().
+				AddText(" ", 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorWhite)
+		},
+	)
+}
+
+func (e *EssaySt) TestRTF(doc *RTFDoc, student uint, qNum *QuestNumSt) {
+	questions, _ := e.Questions.Get(int(student))
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			rtfQuestions(doc, QuestionSetSt{List: arraylist.New(q)}, &e.SectionHeadSt, qNum)
+			if q.Rubric == nil {
+				return
+			}
+			t := doc.AddTable().
+				SetWidth(tableWidth).
+				SetMarginLeft(50).
+				SetMarginRight(50).
+				SetMarginTop(50).
+				SetMarginBottom(50)
+			q.Rubric.addRTF(doc, t)
+			p := doc.AddParagraph()
+			p.AddText(" ", 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorWhite)
+		},
+	)
+}
+
+func (d *DiagramSt) TestRTF(doc *RTFDoc, student uint, qNum *QuestNumSt) {
+	t := doc.AddTable().
+		SetWidth(tableWidth).
+		SetMarginLeft(50).
+		SetMarginRight(50).
+		SetMarginTop(50).
+		SetMarginBottom(50).
+		SetBorderColor(rtfdoc.ColorWhite)
+
+	if words := d.WordBank.get(int(student)); words.Size() != 0 {
+		doc.addText(t.AddTableRow().AddDataCell(tableWidth).AddParagraph().SetAlign(rtfdoc.AlignCenter),
+			words.join("     "))
+	}
+
+	questions, _ := d.Questions.Get(int(student))
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			text := q.Question.string
+			if d.QuestionPoints {
+				text += " " + pointsText(q.Points)
+			}
+			doc.addText(t.AddTableRow().AddDataCell(tableWidth).AddParagraph(), text)
+			doc.addDiagramRTF(t, q, qNum)
+			t.AddTableRow().AddDataCell(tableWidth).AddParagraph().
+				AddText(" ", 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorWhite)
+		},
+	)
+}
+
 func (w *WordProblemSt) TestRTF(doc *RTFDoc, student uint, qNum *QuestNumSt) {
 	questions, _ := w.Questions.Get(int(student))
 	rtfQuestions(doc, questions, &w.SectionHeadSt, qNum)
@@ -205,21 +346,26 @@ func (w *WordMatchSt) TestRTF(doc *RTFDoc, student uint, qNum *QuestNumSt) {
 		AddText(w.ColumnHead.Values()[1],
 			12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack).SetBold()
 
-	rows, _ := w.Get(int(student))
-	rows.Each(
-		func(i int, row *WordDefSt) {
-			tr := t.AddTableRow()
-			tr.AddDataCell(cWidth[0]).
-				AddParagraph().
-				AddText(fmt.Sprintf("%d. %s", qNum.NextNumber(), row.Word.rtfString()),
-					12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+	words := w.getWords(student)
+	options := w.getDefs(student)
+	for i := 0; i < max(words.Size(), options.Size()); i++ {
+		tr := t.AddTableRow()
+		word := " "
+		if row, found := words.Get(i); found {
+			word = fmt.Sprintf("%d. %s", qNum.NextNumber(), row.rtfString())
+		}
+		tr.AddDataCell(cWidth[0]).
+			AddParagraph().
+			AddText(word, 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
 
-			tr.AddDataCell(cWidth[1]).
-				AddParagraph().
-				AddText(fmt.Sprintf(charStrFmt, 'A'+i, row.Def.rtfString()),
-					12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
-		},
-	)
+		option := " "
+		if def, found := options.Get(i); found {
+			option = fmt.Sprintf(charStrFmt, 'A'+i, def.rtfString())
+		}
+		tr.AddDataCell(cWidth[1]).
+			AddParagraph().
+			AddText(option, 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+	}
 	p := doc.AddParagraph()
 	p.AddText(" ", 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorWhite)
 }
@@ -271,11 +417,20 @@ func rtfQuestions(doc *RTFDoc, questions QuestionSetSt, head *SectionHeadSt, qNu
 
 	questions.Each(
 		func(_ int, q *QuestionsSt) {
-			t.AddTableRow().
-				AddDataCell(tableWidth).
-				AddParagraph().
-				AddText(fmt.Sprintf("%d. %s", qNum.NextNumber(), q.Question.rtfString()),
-					12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+			if q.Image.above() {
+				rtfImage(t, q.Image)
+			}
+			question := fmt.Sprintf("%d. %s", qNum.NextNumber(), q.Question.string)
+			if q.selectAll() {
+				question += " " + SelectAllNote
+			}
+			if head.QuestionPoints || len(q.PartPoints) != 0 {
+				question += " " + pointsText(q.Points)
+			}
+			doc.addText(t.AddTableRow().AddDataCell(tableWidth).AddParagraph(), question)
+			if q.Image != nil && !q.Image.above() {
+				rtfImage(t, q.Image)
+			}
 
 			cWidth := int(tableWidth / q.NumCol)
 			var cRow *rtfdoc.TableRow
@@ -284,29 +439,75 @@ func rtfQuestions(doc *RTFDoc, questions QuestionSetSt, head *SectionHeadSt, qNu
 					if ci%int(q.NumCol) == 0 {
 						cRow = t.AddTableRow()
 					}
-					cRow.AddDataCell(cWidth).
-						AddParagraph().
-						AddText(fmt.Sprintf(charStrFmt, 'A'+ci, c),
-							12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+					cell := cRow.AddDataCell(cWidth)
+					if img := q.choiceImage(ci); img != nil {
+						img.addRTF(cell.AddParagraph(), cWidth, choiceImageWidth)
+					}
+					doc.addText(cell.AddParagraph(), fmt.Sprintf(charStrFmt, 'A'+ci, c))
 				},
 			)
 
 			q.Parts.Each(
 				func(pi int, part NLStringSt) {
-					t.AddTableRow().
-						AddDataCell(tableWidth).
-						AddParagraph().
-						AddText(fmt.Sprintf(charStrFmt, 'a'+pi, part.rtfString()),
-							12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+					text := fmt.Sprintf(charStrFmt, 'a'+pi, part.string)
+					if points, found := q.partPoints(pi); found {
+						text += " " + pointsText(points)
+					}
+					doc.addText(t.AddTableRow().AddDataCell(tableWidth).AddParagraph(), text)
 				},
 			)
 
+			if q.Ordering {
+				doc.addText(t.AddTableRow().AddDataCell(tableWidth).AddParagraph(),
+					"Order: "+q.orderBlanks("_____"))
+			}
+
+			if q.Numeric != nil {
+				doc.addText(t.AddTableRow().AddDataCell(tableWidth).AddParagraph(),
+					strings.TrimSpace("Answer: ________________ "+q.numericUnits()))
+			}
+
 			bCol := t.AddTableRow().AddDataCell(tableWidth).AddParagraph()
 			bCol.AddText(" ", 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorWhite)
 		},
 	)
 }
 
+// addText adds text to p with its math as pictures when the document has a
+// math renderer, or in Unicode otherwise.
+func (doc *RTFDoc) addText(p *rtfdoc.Paragraph, text string) {
+	if doc.Math == nil || !hasMath(text) {
+		p.AddText(rtfEscape(MathUnicode(text)), 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+		return
+	}
+
+	last := 0
+	for _, span := range mathRe.FindAllStringSubmatchIndex(text, -1) {
+		start, end := span[4], span[5]
+		p.AddText(rtfEscape(text[last:start]), 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+		last = end
+
+		pngPath, err := doc.Math.Render(text[start:end])
+		if err != nil {
+			log.Println("Unable to render math, error: ", err)
+			p.AddText(rtfEscape(MathUnicode(text[start:end])),
+				12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+			continue
+		}
+		source, _ := os.ReadFile(pngPath)
+		pic := p.AddPicture(source, rtfdoc.ImageFormatPng)
+		pic.SetWidthKeepRatio(pic.GetWidth() * 96 / int(doc.Math.DPI))
+	}
+	p.AddText(rtfEscape(text[last:]), 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+}
+
+func rtfImage(t *rtfdoc.Table, img *ImageSt) {
+	img.addRTF(t.AddTableRow().
+		AddDataCell(tableWidth).
+		AddParagraph().
+		SetAlign(rtfdoc.AlignCenter), tableWidth, stemImageWidth)
+}
+
 func rtfText(doc *RTFDoc, title, text string) {
 	p := doc.AddParagraph().SetAlign(rtfdoc.AlignCenter)
 	p.AddText(title, 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack).SetBold()
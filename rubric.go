@@ -0,0 +1,139 @@
+package testparts
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	rtfdoc "github.com/abaskin/testparts/rtf-doc"
+)
+
+// A rubric marks an essay question. It names its performance "levels", best
+// first, and lists its "criteria", each with a "name", the "points" earned at
+// every level and optionally "descriptors" saying what each level looks
+// like. A section's rubric marks the questions without their own, and a
+// question with a rubric is worth the most it can score.
+
+type RubricSt struct {
+	Levels   []string            `json:"levels"`
+	Criteria []RubricCriterionSt `json:"criteria"`
+}
+
+type RubricCriterionSt struct {
+	Name        string   `json:"name"`
+	Points      []uint   `json:"points"`
+	Descriptors []string `json:"descriptors"`
+}
+
+// RubricScoreSt is the level a student reached on one criterion.
+type RubricScoreSt struct {
+	Criterion string `json:"criterion"`
+	Level     string `json:"level"`
+	Points    uint   `json:"points"`
+}
+
+// AttemptAnswersSt is what GormTestAttempt.Answers holds: the rubric scores
+// of each question, by GormQuestion ID.
+type AttemptAnswersSt struct {
+	Rubrics map[uint][]RubricScoreSt `json:"rubrics,omitempty"`
+}
+
+func (r *RubricSt) maxPoints() uint {
+	total := uint(0)
+	for _, criterion := range r.Criteria {
+		if len(criterion.Points) != 0 {
+			total += slices.Max(criterion.Points)
+		}
+	}
+	return total
+}
+
+// cell is what the grid shows for the criterion at a level.
+func (c RubricCriterionSt) cell(level int) string {
+	points := uint(0)
+	if level < len(c.Points) {
+		points = c.Points[level]
+	}
+	if level < len(c.Descriptors) && c.Descriptors[level] != "" {
+		return fmt.Sprintf("%s (%d)", c.Descriptors[level], points)
+	}
+	return fmt.Sprintf("%d", points)
+}
+
+// latex is the rubric as a scoring grid with a column for the marker.
+func (r *RubricSt) latex() []string {
+	outStr := []string{
+		`\noindent`,
+		fmt.Sprintf(`\begin{tabularx}{\textwidth}{|l|*{%d}{X|}c|}`, len(r.Levels)),
+		`\hline`,
+		strings.Join(append(append([]string{`Criterion`}, r.Levels...), `Score`), " & ") + ` \\ \hline`,
+	}
+	for _, criterion := range r.Criteria {
+		row := []string{criterion.Name}
+		for level := range r.Levels {
+			row = append(row, criterion.cell(level))
+		}
+		outStr = append(outStr, strings.Join(append(row, `\hspace{1cm}`), " & ")+` \\ \hline`)
+	}
+	return append(outStr,
+		fmt.Sprintf(`\multicolumn{%d}{|r|}{Total} & /%d \\ \hline`, len(r.Levels)+1, r.maxPoints()),
+		`\end{tabularx}`,
+		`\vspace{0.25cm}`)
+}
+
+// addRTF adds the rubric as a scoring grid with a column for the marker.
+func (r *RubricSt) addRTF(doc *RTFDoc, t *rtfdoc.Table) {
+	ratios := make([]float64, len(r.Levels)+2)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	cWidth := t.GetTableCellWidthByRatio(ratios...)
+	score := len(r.Levels) + 1
+
+	tr := t.AddTableRow()
+	for ci, heading := range append(append([]string{"Criterion"}, r.Levels...), "Score") {
+		tr.AddDataCell(cWidth[ci]).AddParagraph().
+			AddText(rtfEscape(heading), 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack).SetBold()
+	}
+	for _, criterion := range r.Criteria {
+		tr := t.AddTableRow()
+		doc.addText(tr.AddDataCell(cWidth[0]).AddParagraph(), criterion.Name)
+		for level := range r.Levels {
+			doc.addText(tr.AddDataCell(cWidth[level+1]).AddParagraph(), criterion.cell(level))
+		}
+		tr.AddDataCell(cWidth[score]).AddParagraph()
+	}
+
+	tr = t.AddTableRow()
+	total := 0
+	for _, width := range cWidth[:score] {
+		total += width
+	}
+	tr.AddDataCell(total).AddParagraph().SetAlign(rtfdoc.AlignRight).
+		AddText("Total", 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack).SetBold()
+	tr.AddDataCell(cWidth[score]).AddParagraph().
+		AddText(fmt.Sprintf("/%d", r.maxPoints()), 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+}
+
+// gormRubric is the rubric as stored with its question.
+func (r *RubricSt) gormRubric() []GormRubricCriterion {
+	if r == nil {
+		return nil
+	}
+	criteria := make([]GormRubricCriterion, 0, len(r.Criteria))
+	for _, criterion := range r.Criteria {
+		levels := make([]GormRubricLevel, 0, len(r.Levels))
+		for li, level := range r.Levels {
+			gormLevel := GormRubricLevel{Level: level}
+			if li < len(criterion.Points) {
+				gormLevel.Points = criterion.Points[li]
+			}
+			if li < len(criterion.Descriptors) {
+				gormLevel.Descriptor = criterion.Descriptors[li]
+			}
+			levels = append(levels, gormLevel)
+		}
+		criteria = append(criteria, GormRubricCriterion{Name: criterion.Name, Levels: levels})
+	}
+	return criteria
+}
@@ -0,0 +1,36 @@
+package testparts
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Every random choice made while generating a test comes from a source
+// seeded from TestJSONSt.Seed. Each section derives its own seed from the
+// test seed and its position, and each student's copy of a section derives
+// one from the section seed and the student number, so the same seed and
+// student list always produce the same tests.
+
+// NewSeed returns a seed for a test that does not set one.
+func NewSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// deriveSeed mixes index into seed with the SplitMix64 finalizer, so nearby
+// indexes give unrelated seeds.
+func deriveSeed(seed int64, index uint) int64 {
+	z := uint64(seed) + (uint64(index)+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+func newRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// studentRand returns the random source for one student's copy of the
+// section seeded with seed.
+func studentRand(seed int64, student uint) *rand.Rand {
+	return newRand(deriveSeed(seed, student))
+}
@@ -0,0 +1,304 @@
+package testparts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Test specifications and include files may be written as JSONC, YAML or
+// TOML, chosen by file extension. YAML and TOML documents are converted to
+// JSON so they go through the same schema and unmarshalling as JSONC files.
+
+// ---- YAML ----
+
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+func loadYAMLSpec(filePath string) (*specFileSt, error) {
+	source, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &specFileSt{path: filePath, positions: map[string]specPosSt{}}
+	root := yaml.Node{}
+	if err := yaml.Unmarshal(source, &root); err != nil {
+		syntaxErr := ValidationErrorSt{File: filePath, Message: err.Error()}
+		if line := yamlLineRe.FindStringSubmatch(err.Error()); line != nil {
+			fmt.Sscan(line[1], &syntaxErr.Line)
+			syntaxErr.Column = 1
+		}
+		return spec, ValidationErrorsSt{syntaxErr}
+	}
+
+	doc, err := spec.yamlValue(&root, "")
+	if err == nil {
+		spec.data, err = json.Marshal(doc)
+	}
+	if err != nil {
+		return spec, ValidationErrorsSt{spec.errorAt("", "%v", err)}
+	}
+	return spec, nil
+}
+
+// yamlValue converts a YAML node to the value encoding/json would have
+// produced for the equivalent JSON, recording where each value starts.
+// Scalars YAML would resolve to timestamps are kept as written.
+func (sf *specFileSt) yamlValue(node *yaml.Node, pointer string) (any, error) {
+	if node.Kind != yaml.DocumentNode {
+		sf.positions[pointer] = specPosSt{line: uint(node.Line), column: uint(node.Column)}
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return sf.yamlValue(node.Content[0], pointer)
+
+	case yaml.AliasNode:
+		return sf.yamlValue(node.Alias, pointer)
+
+	case yaml.SequenceNode:
+		values := make([]any, len(node.Content))
+		for i, item := range node.Content {
+			value, err := sf.yamlValue(item, fmt.Sprintf("%s/%d", pointer, i))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+
+	case yaml.MappingNode:
+		values := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			value, err := sf.yamlValue(node.Content[i+1],
+				pointer+"/"+pointerEscaper.Replace(key))
+			if err != nil {
+				return nil, err
+			}
+			values[key] = value
+		}
+		return values, nil
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool", "!!int", "!!float":
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return nil, fmt.Errorf("line %d: %v", node.Line, err)
+		}
+		return value, nil
+	default:
+		return node.Value, nil
+	}
+}
+
+// ---- TOML ----
+
+func loadTOMLSpec(filePath string) (*specFileSt, error) {
+	source, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &specFileSt{path: filePath, positions: indexTOMLPositions(string(source))}
+	doc := map[string]any{}
+	if _, err := toml.Decode(string(source), &doc); err != nil {
+		syntaxErr := ValidationErrorSt{File: filePath, Message: err.Error()}
+		parseErr := toml.ParseError{}
+		if errors.As(err, &parseErr) {
+			pos := offsetPosition(source, parseErr.Position.Start)
+			syntaxErr.Line, syntaxErr.Column = pos.line, pos.column
+		}
+		return spec, ValidationErrorsSt{syntaxErr}
+	}
+
+	if spec.data, err = json.Marshal(tomlDates(doc)); err != nil {
+		return spec, ValidationErrorsSt{spec.errorAt("", "%v", err)}
+	}
+	return spec, nil
+}
+
+// tomlDates replaces the dates and times in a decoded TOML value with their
+// TOML text, local ones without the offset the decoder gives them.
+func tomlDates(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			value[key] = tomlDates(item)
+		}
+	case []map[string]any:
+		for _, item := range value {
+			tomlDates(item)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = tomlDates(item)
+		}
+	case time.Time:
+		switch value.Location().String() {
+		case "date-local":
+			return value.Format(time.DateOnly)
+		case "time-local":
+			return value.Format("15:04:05.999999999")
+		case "datetime-local":
+			return value.Format("2006-01-02T15:04:05.999999999")
+		}
+		return value.Format(time.RFC3339Nano)
+	}
+	return value
+}
+
+func offsetPosition(source []byte, offset int) specPosSt {
+	pos := specPosSt{line: 1, column: 1}
+	for i := 0; i < offset && i < len(source); i++ {
+		switch {
+		case source[i] == '\n':
+			pos.line++
+			pos.column = 1
+		case source[i]&0xC0 != 0x80:
+			pos.column++
+		}
+	}
+	return pos
+}
+
+// indexTOMLPositions records the line of every table header and key in a
+// TOML document. Values nested inside inline tables or arrays are reported
+// at the key that holds them.
+func indexTOMLPositions(source string) map[string]specPosSt {
+	positions := map[string]specPosSt{"": {line: 1, column: 1}}
+	arrays := map[string]int{}
+	table := ""
+	value := &tomlValueSt{}
+
+	for li, line := range strings.Split(source, "\n") {
+		trimmed := strings.TrimSpace(line)
+		pos := specPosSt{
+			line:   uint(li + 1),
+			column: uint(strings.Index(line, trimmed) + 1),
+		}
+
+		switch {
+		case value.open():
+			value.scan(line)
+
+		case trimmed == "", strings.HasPrefix(trimmed, "#"):
+
+		case strings.HasPrefix(trimmed, "[["):
+			name, _, _ := strings.Cut(strings.TrimPrefix(trimmed, "[["), "]]")
+			keys := splitTOMLKey(name)
+			array := tomlPointer(keys[:len(keys)-1], arrays) + tomlPointer(keys[len(keys)-1:], nil)
+			index, found := arrays[array]
+			arrays[array] = ternary(found, index+1, 0)
+			if !found {
+				positions[array] = pos
+			}
+			table = fmt.Sprintf("%s/%d", array, arrays[array])
+			positions[table] = pos
+
+		case strings.HasPrefix(trimmed, "["):
+			name, _, _ := strings.Cut(strings.TrimPrefix(trimmed, "["), "]")
+			table = tomlPointer(splitTOMLKey(name), arrays)
+			positions[table] = pos
+
+		default:
+			key, rest, found := strings.Cut(trimmed, "=")
+			if found {
+				positions[table+tomlPointer(splitTOMLKey(key), nil)] = pos
+				value.scan(rest)
+			}
+		}
+	}
+
+	return positions
+}
+
+// tomlValueSt follows a value across lines until its brackets and strings
+// are closed, so keys inside multi-line values are not mistaken for
+// top-level ones.
+type tomlValueSt struct {
+	depth int
+	quote string
+}
+
+func (tv *tomlValueSt) open() bool {
+	return tv.depth > 0 || tv.quote != ""
+}
+
+func (tv *tomlValueSt) scan(line string) {
+	for i := 0; i < len(line); i++ {
+		switch {
+		case tv.quote != "":
+			switch {
+			case tv.quote[0] == '"' && line[i] == '\\':
+				i++
+			case strings.HasPrefix(line[i:], tv.quote):
+				i += len(tv.quote) - 1
+				tv.quote = ""
+			}
+		case strings.HasPrefix(line[i:], `"""`), strings.HasPrefix(line[i:], `'''`):
+			tv.quote = line[i : i+3]
+			i += 2
+		case line[i] == '"' || line[i] == '\'':
+			tv.quote = line[i : i+1]
+		case line[i] == '#':
+			return
+		case line[i] == '[' || line[i] == '{':
+			tv.depth++
+		case line[i] == ']' || line[i] == '}':
+			tv.depth--
+		}
+	}
+
+	if len(tv.quote) == 1 {
+		tv.quote = ""
+	}
+}
+
+// tomlPointer turns dotted TOML keys into a JSON pointer, stepping into the
+// latest element of any array of tables along the way.
+func tomlPointer(keys []string, arrays map[string]int) string {
+	pointer := ""
+	for _, key := range keys {
+		pointer += "/" + pointerEscaper.Replace(key)
+		if index, found := arrays[pointer]; found {
+			pointer = fmt.Sprintf("%s/%d", pointer, index)
+		}
+	}
+	return pointer
+}
+
+func splitTOMLKey(key string) []string {
+	keys := make([]string, 0)
+	current := strings.Builder{}
+	quote := rune(0)
+	for _, ch := range strings.TrimSpace(key) {
+		switch {
+		case quote != 0 && ch == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(ch)
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '.':
+			keys = append(keys, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(ch)
+		}
+	}
+	return append(keys, strings.TrimSpace(current.String()))
+}
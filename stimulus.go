@@ -0,0 +1,187 @@
+package testparts
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	rtfdoc "github.com/abaskin/testparts/rtf-doc"
+	"github.com/daichi-m/go18ds/lists/arraylist"
+	"github.com/nwillc/genfuncs"
+)
+
+// A stimulus-groups section holds a pool of "stimuli", each a passage
+// ("text"), a "table" whose first row is its heading, an "image" or any of
+// them, with a "title" and its own "questions". Each copy draws
+// "groupsOnTest" stimuli, all of them by default, balancing how often each
+// is used like questions, then "questionsOntest" of each stimulus's
+// questions, all of them by default. A "required" stimulus is on every copy.
+// The section's points are shared by all the questions on a copy.
+
+type StimulusSt struct {
+	Title     string        `json:"title"`
+	Text      NLStringSt    `json:"text"`
+	Table     [][]string    `json:"table"`
+	Image     *ImageSt      `json:"image"`
+	Required  bool          `json:"required"`
+	NumQuest  uint          `json:"questionsOntest"`
+	Questions QuestionSetSt `json:"questions"`
+	Used      uint          `json:"-"`
+}
+
+// StimulusGroupSt is a stimulus with the questions drawn for one copy.
+type StimulusGroupSt struct {
+	*StimulusSt
+	Questions QuestionSetSt
+}
+
+// stimulusPoints returns what every copy of a stimulus-groups section is
+// worth, checking the questions of all its stimuli like a pool drawn for the
+// fewest and the most questions a copy can have.
+func (section JSONSectionSt) stimulusPoints() (uint, error) {
+	pool, counts := make([]*QuestionsSt, 0), make([]uint, 0, len(section.Stimuli))
+	for _, stimulus := range section.Stimuli {
+		stimulus.Questions.fixMissing()
+		pool = append(pool, stimulus.Questions.Values()...)
+		count := uint(stimulus.Questions.Size())
+		if stimulus.NumQuest != 0 {
+			count = genfuncs.Min(stimulus.NumQuest, count)
+		}
+		counts = append(counts, count)
+	}
+	slices.Sort(counts)
+	numGroups := genfuncs.Min(ternary(section.GroupsOnTest != 0, section.GroupsOnTest, uint(len(counts))),
+		uint(len(counts)))
+	fewest, most := sumPoints(counts[:numGroups]), sumPoints(counts[uint(len(counts))-numGroups:])
+
+	points, err := sectionPoints(pool, most, section.Points)
+	if err != nil || fewest == most {
+		return points, err
+	}
+	fewestPoints, err := sectionPoints(pool, fewest, section.Points)
+	if err == nil && fewestPoints != points {
+		err = fmt.Errorf("copies of %d to %d questions can be worth from %d to %d points",
+			fewest, most, fewestPoints, points)
+	}
+	return points, err
+}
+
+// stimulusSet draws numGroups of stimuli for a copy, the least used first.
+func stimulusSet(stimuli []*StimulusSt, numGroups, numTest uint,
+	keepOrder bool, rnd *rand.Rand) []*StimulusSt {
+	numGroups = genfuncs.Min(numGroups, uint(len(stimuli)))
+	if keepOrder {
+		for _, stimulus := range stimuli[:numGroups] {
+			stimulus.Used++
+		}
+		return stimuli[:numGroups]
+	}
+
+	newSet := arraylist.New[*StimulusSt]()
+	for _, stimulus := range stimuli {
+		if stimulus.Required && newSet.Size() < int(numGroups) {
+			newSet.Add(stimulus)
+			stimulus.Used++
+		}
+	}
+	for newSet.Size() < int(numGroups) {
+		minUse := numTest + 1
+		for _, stimulus := range stimuli {
+			if !newSet.Contains(stimulus) {
+				minUse = genfuncs.Min(minUse, stimulus.Used)
+			}
+		}
+		least := make([]*StimulusSt, 0)
+		for _, stimulus := range stimuli {
+			if !newSet.Contains(stimulus) && stimulus.Used == minUse {
+				least = append(least, stimulus)
+			}
+		}
+		winner := shuffleSlice(rnd, least)[0]
+		newSet.Add(winner)
+		winner.Used++
+	}
+	return shuffleSlice(rnd, newSet.Values())
+}
+
+// group draws the stimulus's questions for a copy.
+func (s *StimulusSt) group(numTest, numCol uint, keepOrder bool, rnd *rand.Rand) StimulusGroupSt {
+	numQuest := uint(s.Questions.Size())
+	if s.NumQuest != 0 {
+		numQuest = genfuncs.Min(s.NumQuest, numQuest)
+	}
+	questions := QuestionSetSt{List: arraylist.New[*QuestionsSt]()}
+	s.Questions.QuestionSet(numQuest, numTest, keepOrder, rnd).Each(
+		func(_ int, q *QuestionsSt) {
+			questions.Add(q.copyFor(numCol, rnd))
+		},
+	)
+	return StimulusGroupSt{StimulusSt: s, Questions: questions}
+}
+
+// tableText is the stimulus's table as lines of text, for Google Forms.
+func (s *StimulusSt) tableText() string {
+	rows := make([]string, 0, len(s.Table))
+	for _, row := range s.Table {
+		rows = append(rows, strings.Join(row, " | "))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// latex shows the stimulus above its questions.
+func (s *StimulusSt) latex() []string {
+	outStr := []string{fmt.Sprintf(`\qtitle{%s}`, s.Title)}
+	if s.Text.string != "" {
+		outStr = append(outStr, s.Text.string, `\\`)
+	}
+	if len(s.Table) != 0 {
+		cols := 0
+		for _, row := range s.Table {
+			cols = genfuncs.Max(cols, len(row))
+		}
+		outStr = append(outStr, `\begin{center}`,
+			fmt.Sprintf(`\begin{tabular}{|%s}`, strings.Repeat("l|", cols)),
+			`\hline`)
+		for ri, row := range s.Table {
+			outStr = append(outStr, strings.Join(row, " & ")+ternary(ri == 0, ` \\ \hline\hline`, ` \\ \hline`))
+		}
+		outStr = append(outStr, `\end{tabular}`, `\end{center}`)
+	}
+	if s.Image != nil && s.Image.File != "" {
+		outStr = append(outStr, `\begin{center}`, s.Image.latex(stemImageWidth), `\end{center}`)
+	}
+	return outStr
+}
+
+// addStimulusRTF adds the stimulus above its questions.
+func (doc *RTFDoc) addStimulusRTF(s *StimulusSt) {
+	rtfText(doc, s.Title, s.Text.string)
+
+	if len(s.Table) != 0 {
+		t := doc.AddTable().
+			SetWidth(tableWidth).
+			SetMarginLeft(50).
+			SetMarginRight(50).
+			SetMarginTop(50).
+			SetMarginBottom(50)
+		cols := 0
+		for _, row := range s.Table {
+			cols = genfuncs.Max(cols, len(row))
+		}
+		for ri, row := range s.Table {
+			tr := t.AddTableRow()
+			for _, cell := range row {
+				text := tr.AddDataCell(tableWidth/cols).AddParagraph().
+					AddText(rtfEscape(cell), 12, rtfdoc.FontTimesNewRoman, rtfdoc.ColorBlack)
+				if ri == 0 {
+					text.SetBold()
+				}
+			}
+		}
+	}
+
+	if s.Image != nil {
+		rtfImage(doc.AddTable().SetWidth(tableWidth).SetBorderColor(rtfdoc.ColorWhite), s.Image)
+	}
+}
@@ -2,7 +2,6 @@ package testparts
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -13,26 +12,28 @@ import (
 	"github.com/nwillc/genfuncs"
 	"github.com/rwestlund/gotex"
 	"gorm.io/gorm"
-	"muzzammil.xyz/jsonc"
 )
 
 func GetTestJson(fileName, assetdir string) (TestJSONSt, error) {
 	filePath := assetdir + "/" + fileName
-	_, bytes, err := jsonc.ReadFromFile(filePath)
+	testJSON, err := readTestJson(filePath, assetdir)
 
+	if validationErrs, ok := err.(ValidationErrorsSt); ok {
+		log.Printf("Test file failed validation, %d errors:\n%s\n",
+			len(validationErrs), validationErrs.Error())
+		return TestJSONSt{}, err
+	}
 	if err != nil {
 		log.Println("Unable to load test file, error: ", err)
 		return TestJSONSt{}, err
 	}
 
-	testJSON := TestJSONSt{}
+	testJSON.Logo, _ = filepath.Abs(assetdir + "/" + testJSON.Logo)
 
-	if err := json.Unmarshal(bytes, &testJSON); err != nil {
-		log.Println("Unable to parse test file, error: ", err)
-		return TestJSONSt{}, err
+	if testJSON.Seed == 0 {
+		testJSON.Seed = NewSeed()
 	}
-
-	testJSON.Logo, _ = filepath.Abs(assetdir + "/" + testJSON.Logo)
+	log.Println("Test seed:", testJSON.Seed)
 
 	return testJSON, nil
 }
@@ -40,23 +41,31 @@ func GetTestJson(fileName, assetdir string) (TestJSONSt, error) {
 func MakeSections(testJSON TestJSONSt, assetDir string, showAll bool) ([]SectionSt, error) {
 	sections := make([]SectionSt, 0)
 
-	for _, section := range testJSON.Sections {
+	for si, section := range testJSON.Sections {
 		log.Println("Making section:", section.SectionTitle)
 		section.AnswerText.fixMissing()
 		section.WordList.fixMissing()
 		section.Include.fixMissing()
 		section.IncludeQuestgen.fixMissing()
 		section.IncludeAiken.fixMissing()
+		section.IncludeGift.fixMissing()
+		section.IncludeMoodle.fixMissing()
+		section.IncludeQTI.fixMissing()
+		section.IncludeMarkdown.fixMissing()
 		section.Answers.fixMissing()
 		section.ColumnHead.fixMissing()
 		section.Words.fixMissing()
 		section.Questions.fixMissing()
 		section.AssetDir = assetDir
+		section.Seed = deriveSeed(testJSON.Seed, uint(si))
 		numTests := uint(testJSON.Students.Size())
 		ProcessInclude(section, assetDir)
 
 		if section.NumQuest == 0 {
 			section.NumQuest = uint(genfuncs.Min(section.Questions.Size(), section.Words.Size()))
+			if len(section.Blueprint) != 0 {
+				section.NumQuest = section.Blueprint.total()
+			}
 		}
 
 		if section.NumCol == 0 {
@@ -68,61 +77,46 @@ func MakeSections(testJSON TestJSONSt, assetDir string, showAll bool) ([]Section
 				if q.Required {
 					q.Used = numTests + 1
 				}
+				q.resolveImages(assetDir)
+				if q.Rubric == nil {
+					q.Rubric = section.Rubric
+				}
 			},
 		)
 
 		if showAll {
 			numTests = 1
 			section.NumQuest = uint(section.Questions.Size())
+			section.Blueprint = nil
+		}
+
+		if len(section.Blueprint) != 0 {
+			if err := section.Blueprint.check(section.Questions.Values(), section.NumQuest); err != nil {
+				return sections, fmt.Errorf("section %s: %v", section.SectionTitle, err)
+			}
 		}
 
-		switch section.Type {
-		case "word-match":
-			if showAll {
-				section.NumQuest = uint(section.Words.Size())
+		if section.Type != "word-match" {
+			points, err := sectionPoints(section.Questions.Values(), section.NumQuest, section.Points)
+			if section.Type == "stimulus-groups" {
+				points, err = section.stimulusPoints()
 			}
-			w := new(WordMatchSt)
-			w.Init(section, numTests)
-			sections = append(sections, w)
-
-		case "multiple-choice":
-			m := new(MultipleChoiceSt)
-			m.Init(section, numTests)
-			sections = append(sections, m)
-
-		case "word-problem":
-			w := new(WordProblemSt)
-			w.Init(section, numTests)
-			sections = append(sections, w)
-
-		case "quiz":
-			q := new(QuizSt)
-			q.Init(section, numTests)
-			sections = append(sections, q)
-
-		case "reading-comprehension":
-			r := new(ReadingCompSt)
-			r.Init(section, numTests)
-			sections = append(sections, r)
-
-		case "comprehension-questions":
-			c := new(CompQuestionsSt)
-			c.Init(section, numTests)
-			sections = append(sections, c)
-
-		case "passage-completion":
-			p := new(PassageCompletionSt)
-			p.Init(section, numTests)
-			sections = append(sections, p)
-
-		case "custom":
-			c := new(CustomSt)
-			c.Init(section, numTests)
-			sections = append(sections, c)
-
-		default:
-			log.Printf("Error: unknown section type %s\n", section.Type)
+			if err != nil {
+				return sections, fmt.Errorf("section %s: %v", section.SectionTitle, err)
+			}
+			section.Points = points
+		}
+
+		sectionType, found := lookupSectionType(section.Type)
+		if !found {
+			return sections, fmt.Errorf("unknown section type %s", section.Type)
+		}
+		if section.Type == "word-match" && showAll {
+			section.NumQuest = uint(section.Words.Size())
 		}
+		s := sectionType.factory()
+		s.Init(section, numTests)
+		sections = append(sections, s)
 	}
 
 	return sections, nil
@@ -138,6 +132,7 @@ func MakeBundle(dsn string, testJSON TestJSONSt, sections []SectionSt) (*arrayli
 		Logo:     testJSON.Logo,
 		Time:     testJSON.Time,
 		Date:     testJSON.Date,
+		Seed:     testJSON.Seed,
 		NoKey:    testJSON.NoKey,
 		Quiz:     false,
 		Points:   0,
@@ -222,6 +217,38 @@ func (distroTest *TestBundleSt) CreateDistro(pathStrings PathStrSt, flags FlagsS
 	}
 }
 
+func (bundle *TestBundleSt) CreateMoodle(pathStrings PathStrSt, flags FlagsSt, test TestSt) {
+	if flags.CreateMoodle {
+		moodlePath := fmt.Sprintf("%s/%s-moodle.xml", pathStrings.Outdir,
+			strings.ReplaceAll(test.TestJSON.Title, " ", ""))
+		mFile, err := os.Create(moodlePath)
+		if err != nil {
+			log.Println("Unable to create Moodle file, error: ", err)
+			return
+		}
+		defer mFile.Close()
+		if err := ExportMoodle(mFile, test.TestJSON.Title, test.Sections); err != nil {
+			log.Println("Unable to write Moodle file, error: ", err)
+		}
+	}
+}
+
+func (bundle *TestBundleSt) CreateQTI(pathStrings PathStrSt, flags FlagsSt, test TestSt) {
+	if flags.CreateQTI {
+		qtiPath := fmt.Sprintf("%s/%s-qti.zip", pathStrings.Outdir,
+			strings.ReplaceAll(test.TestJSON.Title, " ", ""))
+		qFile, err := os.Create(qtiPath)
+		if err != nil {
+			log.Println("Unable to create QTI package, error: ", err)
+			return
+		}
+		defer qFile.Close()
+		if err := ExportQTI(qFile, test.TestJSON.Title, test.Sections); err != nil {
+			log.Println("Unable to write QTI package, error: ", err)
+		}
+	}
+}
+
 func (bundle *TestBundleSt) createQuiz(pathStrings PathStrSt, flags FlagsSt, test TestSt) error {
 	if flags.CreatePDF {
 		testID := strings.ReplaceAll(bundle.Student, " ", "")
@@ -239,6 +266,26 @@ func (bundle *TestBundleSt) createQuiz(pathStrings PathStrSt, flags FlagsSt, tes
 	return nil
 }
 
+// DBQuestions are the questions of each section type stored in the
+// database.
+
+func (w *WordMatchSt) DBQuestions() QuestionSetSt {
+	w.ToQuestions()
+	return w.AllQuestions
+}
+
+func (m *MultipleChoiceSt) DBQuestions() QuestionSetSt {
+	return m.AllQuestions
+}
+
+func (t *TrueFalseSt) DBQuestions() QuestionSetSt {
+	return t.AllQuestions
+}
+
+func (e *EssaySt) DBQuestions() QuestionSetSt {
+	return e.AllQuestions
+}
+
 func (bundle *TestBundleSt) dbImport(dsn string, pathStrings PathStrSt,
 	flags FlagsSt, test TestSt) error {
 	if flags.DBImport {
@@ -257,51 +304,53 @@ func (bundle *TestBundleSt) dbImport(dsn string, pathStrings PathStrSt,
 			Title:        test.TestJSON.Title,
 			Length:       test.TestJSON.Time,
 			MinQuestions: test.TestJSON.MinQuestions,
+			Seed:         test.TestJSON.Seed,
 			Questions:    make([]GormQuestion, 0),
 		}
 
 		for _, section := range test.Sections {
-			points := section.GetHead().Points / section.GetHead().NumQuest
-			allQuestions := QuestionSetSt{}
-
-			switch section.GetHead().Type {
-			case "word-match":
-				wordMatch := section.(*WordMatchSt)
-				wordMatch.ToQuestions()
-				allQuestions = wordMatch.AllQuestions
-
-			case "multiple-choice":
-				allQuestions = section.(*MultipleChoiceSt).AllQuestions
-
-			// case "word-problem":
-			// case "quiz":
-			// case "reading-comprehension":
-			// case "comprehension-questions":
-			// case "passage-completion":
-			// case "custom":
-
-			default:
+			dbSection, isDB := section.(DBSectionSt)
+			if !supports(section, OutputDB) || !isDB {
 				log.Printf("Warning: unsupported section type %s\n", section.GetHead().Type)
-				allQuestions.Size() // make the linter happy
 				continue
 			}
+			allQuestions := dbSection.DBQuestions()
 
 			log.Printf("%d questions to add", allQuestions.Size())
-			allQuestions.Each(func(_ int, question *QuestionsSt) {
+			head := section.GetHead()
+			points, err := allQuestions.poolPoints(head.NumQuest, head.Points)
+			if err != nil {
+				return fmt.Errorf("section %s: %w", head.SectionTitle, err)
+			}
+			allQuestions.Each(func(qi int, question *QuestionsSt) {
 				choices := make([]GormQuestionChoice, 0)
 				question.Choices.Each(func(index int, choice string) {
 					choices = append(choices,
 						GormQuestionChoice{
-							Choice: choice,
-							Answer: index+1 == int(question.Answer),
+							Choice:    MathUnicode(choice),
+							ChoiceTeX: ternary(hasMath(choice), choice, ""),
+							Image:     question.choiceImage(index).source(),
+							Feedback:  question.choiceFeedback(index),
+							Answer:    question.isCorrect(index),
 						})
 				})
+				// Only a wrong choice costs points.
+				penalty := ternary(len(choices) != 0, head.NegativeMarking*float64(points[qi]), 0)
 				newTest.Questions = append(newTest.Questions,
 					GormQuestion{
 						Required: question.Required,
 						Question: question.Question.CleanString(),
-						Points:   points,
-						Choices:  choices,
+						QuestionTeX: ternary(hasMath(question.Question.string),
+							question.Question.string, ""),
+						Image:       question.Image.source(),
+						ImageWidth:  question.Image.width(stemImageWidth),
+						ImageAbove:  question.Image.above(),
+						SelectAll:   question.selectAll(),
+						KeepChoices: question.KeepChoices,
+						Points:      points[qi],
+						Penalty:     penalty,
+						Choices:     choices,
+						Rubric:      question.Rubric.gormRubric(),
 					})
 			})
 		}
@@ -339,8 +388,12 @@ func (bundle *TestBundleSt) createRTF(pathStrings PathStrSt, flags FlagsSt, test
 		qNum := MakeQuestNum(!flags.ContinuousNumbering)
 		rtf := new(RTFDoc)
 		rtf.Init()
-		rtf.TestHeader(bundle.TestHeadSt, test.Sections)
-		rtf.Sections(bundle.StudentNum, test.Sections, &qNum)
+		if flags.MathImages {
+			rtf.Math = NewMathRenderer(pathStrings.Workdir)
+		}
+		sections := sectionsFor(test.Sections, OutputRTF)
+		rtf.TestHeader(bundle.TestHeadSt, sections)
+		rtf.Sections(bundle.StudentNum, sections, &qNum)
 		rtf.PageFooter(bundle.TestHeadSt)
 		return makeRTF(pathStrings.Outdir, testID, "test", rtf)
 	}
@@ -375,8 +428,12 @@ func (bundle *TestBundleSt) createForm(pathStrings PathStrSt, flags FlagsSt, tes
 			return fmt.Errorf("unable to create Google Form, error: %w", err)
 		}
 		for _, section := range test.Sections {
-			section.TestForm(form, bundle.StudentNum)
-			if err != nil {
+			if !supports(section, OutputForms) {
+				log.Printf("Warning: Google Forms does not support section type %s, section %s is left out\n",
+					section.GetHead().Type, section.GetHead().SectionTitle)
+				continue
+			}
+			if err := section.TestForm(form, bundle.StudentNum); err != nil {
 				log.Printf("Google Form section, error: %s\n", err.Error())
 			}
 		}
@@ -1,28 +1,45 @@
 package testparts
 
 import (
+	"log"
+	"strings"
+
 	"github.com/daichi-m/go18ds/lists/arraylist"
-	"github.com/daichi-m/go18ds/sets/hashset"
+	"github.com/daichi-m/go18ds/sets/linkedhashset"
 )
 
 func (w *WordMatchSt) TestForm(gf *GoogleFormSt, student uint) error {
 	words := w.getWords(student)
-	points := w.Points / uint(words.Size())
 	questions := QuestionSetSt{List: arraylist.New[*QuestionsSt]()}
+	rnd := studentRand(w.Seed, student)
 
 	wordDef, _ := w.Get(int(student))
+	options := wordDef.Options.values()
 	wordDef.Each(
 		func(_ int, wDef *WordDefSt) {
-			choice := hashset.New(wDef.Word.string)
-			for choice.Size() < 5 {
-				choice.Add(words.getRandom())
+			answer := uint(wDef.Answer.string[0]-'A') + 1
+			if len(options) != wordDef.Size() {
+				// With extra or shared options each word picks from them all.
+				questions.Add(
+					&QuestionsSt{
+						Question:    wDef.Word,
+						Answer:      answer,
+						Choices:     WordsSt{List: arraylist.New(options...)},
+						KeepChoices: true,
+					},
+				)
+				return
 			}
 
-			def, _ := w.getDefs(student).Get(int(wDef.Answer.string[0] - 'A'))
+			choice := linkedhashset.New(wDef.Word.string)
+			for choice.Size() < min(5, words.Size()) {
+				choice.Add(words.getRandom(rnd))
+			}
 
 			questions.Add(
 				&QuestionsSt{
-					Question: def,
+					Question: wDef.Def,
+					Answer:   1,
 					Answers:  WordsSt{List: arraylist.New("", wDef.Word.string)},
 					Choices:  WordsSt{List: arraylist.New(choice.Values()...)},
 				},
@@ -30,55 +47,138 @@ func (w *WordMatchSt) TestForm(gf *GoogleFormSt, student uint) error {
 		},
 	)
 
+	questions.allocatePoints(w.Points)
+
 	if err := gf.AddSection(w.SectionTitle, w.FormInstructions); err != nil {
 		return err
 	}
-	return gf.AddQuestions(questions, int64(points))
+	return gf.AddQuestions(questions)
 }
 
 func (c *CompQuestionsSt) TestForm(gf *GoogleFormSt, student uint) error {
 	questions, _ := c.Questions.Get(int(student))
-	points := c.Points / uint(questions.Size())
 	if err := gf.AddSection(c.SectionTitle, c.Text); err != nil {
 		return err
 	}
-	return gf.AddQuestions(questions, int64(points))
+	return gf.AddQuestions(questions)
 }
 
 func (w *WordProblemSt) TestForm(gf *GoogleFormSt, student uint) error {
 	questions, _ := w.Questions.Get(int(student))
-	points := w.Points / uint(questions.Size())
 	if err := gf.AddSection(w.SectionTitle, w.Text); err != nil {
 		return err
 	}
-	return gf.AddQuestions(questions, int64(points))
+	return gf.AddQuestions(questions)
 }
 
 func (m *MultipleChoiceSt) TestForm(gf *GoogleFormSt, student uint) error {
 	questions, _ := m.Questions.Get(int(student))
-	points := m.Points / uint(questions.Size())
+	if m.NegativeMarking > 0 {
+		log.Printf("Warning: Google Forms cannot take points off, section %s is marked without negative marking\n",
+			m.SectionTitle)
+	}
 	if err := gf.AddSection(m.SectionTitle, m.FormInstructions); err != nil {
 		return err
 	}
-	return gf.AddQuestions(questions, int64(points))
+	return gf.AddQuestions(questions)
+}
+
+func (t *TrueFalseSt) TestForm(gf *GoogleFormSt, student uint) error {
+	questions, _ := t.Questions.Get(int(student))
+	if t.CorrectFalse {
+		log.Printf("Warning: Google Forms only marks true or false, corrections in section %s are left out\n",
+			t.SectionTitle)
+	}
+	if err := gf.AddSection(t.SectionTitle, t.FormInstructions); err != nil {
+		return err
+	}
+	return gf.AddQuestions(questions)
+}
+
+func (n *NumericSt) TestForm(gf *GoogleFormSt, student uint) error {
+	questions, _ := n.Questions.Get(int(student))
+	if err := gf.AddSection(n.SectionTitle, n.FormInstructions); err != nil {
+		return err
+	}
+	return gf.AddQuestions(questions)
+}
+
+func (o *OrderingSt) TestForm(gf *GoogleFormSt, student uint) error {
+	questions, _ := o.Questions.Get(int(student))
+	log.Printf("Warning: Google Forms cannot mark grid questions, section %s is left to mark by hand\n",
+		o.SectionTitle)
+	if err := gf.AddSection(o.SectionTitle, o.FormInstructions); err != nil {
+		return err
+	}
+	return gf.AddQuestions(questions)
+}
+
+func (c *ClozeSt) TestForm(gf *GoogleFormSt, student uint) error {
+	questions, _ := c.Questions.Get(int(student))
+	desc := c.FormInstructions
+	if words := c.WordBank.get(int(student)); words.Size() != 0 {
+		desc = strings.TrimSpace(desc + "\n" + MathUnicode(words.join(", ")))
+	}
+	if err := gf.AddSection(c.SectionTitle, desc); err != nil {
+		return err
+	}
+	return gf.AddClozeQuestions(questions)
+}
+
+func (e *EssaySt) TestForm(gf *GoogleFormSt, student uint) error {
+	questions, _ := e.Questions.Get(int(student))
+	if questions.Any(func(_ int, q *QuestionsSt) bool { return q.Rubric != nil }) {
+		log.Printf("Warning: Google Forms cannot mark with a rubric, essays in section %s are left to mark by hand\n",
+			e.SectionTitle)
+	}
+	if err := gf.AddSection(e.SectionTitle, e.FormInstructions); err != nil {
+		return err
+	}
+	return gf.AddQuestions(questions)
+}
+
+func (d *DiagramSt) TestForm(gf *GoogleFormSt, student uint) error {
+	questions, _ := d.Questions.Get(int(student))
+	desc := d.FormInstructions
+	if words := d.WordBank.get(int(student)); words.Size() != 0 {
+		desc = strings.TrimSpace(desc + "\n" + MathUnicode(words.join(", ")))
+	}
+	if err := gf.AddSection(d.SectionTitle, desc); err != nil {
+		return err
+	}
+	return gf.AddDiagramQuestions(questions)
 }
 
 func (r *ReadingCompSt) TestForm(gf *GoogleFormSt, student uint) error {
 	questions, _ := r.Questions.Get(int(student))
-	points := r.Points / uint(questions.Size())
 	if err := gf.AddSection(r.SectionTitle, r.Text); err != nil {
 		return err
 	}
-	return gf.AddQuestions(questions, int64(points))
+	return gf.AddQuestions(questions)
+}
+
+func (s *StimulusGroupsSt) TestForm(gf *GoogleFormSt, student uint) error {
+	for _, group := range s.Groups[student] {
+		desc := strings.TrimSpace(group.Text.CleanString() + "\n\n" + group.tableText())
+		if err := gf.AddSection(ternary(group.Title != "", group.Title, s.SectionTitle), desc); err != nil {
+			return err
+		}
+		if err := gf.AddImage(group.Title, group.Image); err != nil {
+			return err
+		}
+		if err := gf.AddQuestions(group.Questions); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (q *QuizSt) TestForm(gf *GoogleFormSt, student uint) error {
 	questions, _ := q.Questions.Get(int(student))
-	points := q.Points / uint(questions.Size())
 	if err := gf.AddSection(q.SectionTitle, q.Text); err != nil {
 		return err
 	}
-	return gf.AddQuestions(questions, int64(points))
+	return gf.AddQuestions(questions)
 }
 
 func (c *CustomSt) TestForm(gf *GoogleFormSt, student uint) error {
@@ -86,5 +186,11 @@ func (c *CustomSt) TestForm(gf *GoogleFormSt, student uint) error {
 }
 
 func (p *PassageCompletionSt) TestForm(gf *GoogleFormSt, student uint) error {
-	return nil
+	questions, _ := p.Questions.Get(int(student))
+	passage := NLStringSt{p.Text}
+	desc := strings.TrimSpace(p.FormInstructions + "\n\n" + passage.CleanString())
+	if err := gf.AddSection(p.SectionTitle, desc); err != nil {
+		return err
+	}
+	return gf.AddQuestions(questions)
 }
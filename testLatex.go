@@ -8,14 +8,127 @@ import (
 )
 
 func (m *MultipleChoiceSt) TestLatex(student uint, qNum *QuestNumSt) []string {
-	outStr := testSectionBegin(m.SectionTitle, m.Points, m.Instructions)
+	outStr := testSectionBegin(m.SectionTitle, m.Points, m.instructions())
 	questions, _ := m.Questions.Get(int(student))
 	qNum.NewSection()
 	return append(outStr, questionsLatex(questions, *m.GetHead(), false, qNum)...)
 }
 
+func (t *TrueFalseSt) TestLatex(student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(t.SectionTitle, t.Points, t.instructions())
+	questions, _ := t.Questions.Get(int(student))
+	outStr = append(outStr, `\begin{questions}`,
+		fmt.Sprintf(`\setcounter{question}{%d}`, qNum.CurrentNumber()))
+	qNum.AddNumber(uint32(questions.Size()))
+
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			question := ternary(t.QuestionPoints, fmt.Sprintf(`\question[%d]`, q.Points), `\question`)
+			outStr = append(outStr,
+				fmt.Sprintf(`%s \textbf{T}\quad\textbf{F}\quad %s`, question, q.Question.string))
+			outStr = append(outStr, q.imageLatex()...)
+			if t.CorrectFalse {
+				outStr = append(outStr, `\fillwithlines{1.5cm}`)
+			}
+		},
+	)
+	return append(outStr, `\end{questions}`)
+}
+
+func (n *NumericSt) TestLatex(student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(n.SectionTitle, n.Points, n.instructions())
+	questions, _ := n.Questions.Get(int(student))
+	outStr = append(outStr, `\begin{questions}`,
+		fmt.Sprintf(`\setcounter{question}{%d}`, qNum.CurrentNumber()))
+	qNum.AddNumber(uint32(questions.Size()))
+
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			outStr = q.Begin(outStr, n.QuestionPoints)
+			outStr = append(outStr, `\end{minipage}`,
+				fmt.Sprintf(`\par\hfill Answer: \rule{4cm}{0.4pt}~%s`, q.numericUnits()),
+				`\vspace{0.25cm}`)
+		},
+	)
+	return append(outStr, `\end{questions}`)
+}
+
+func (o *OrderingSt) TestLatex(student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(o.SectionTitle, o.Points, o.instructions())
+	questions, _ := o.Questions.Get(int(student))
+	outStr = append(outStr, `\begin{questions}`,
+		fmt.Sprintf(`\setcounter{question}{%d}`, qNum.CurrentNumber()))
+	qNum.AddNumber(uint32(questions.Size()))
+
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			outStr = q.Begin(outStr, o.QuestionPoints)
+			outStr = q.Choice(outStr, q.NumCol)
+			outStr = append(outStr, `\end{minipage}`,
+				fmt.Sprintf(`\par\hfill Order: %s`, q.orderBlanks(`\rule{1cm}{0.4pt}`)),
+				`\vspace{0.25cm}`)
+		},
+	)
+	return append(outStr, `\end{questions}`)
+}
+
+func (c *ClozeSt) TestLatex(student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(c.SectionTitle, c.Points, c.instructions())
+	if words := c.WordBank.get(int(student)); words.Size() != 0 {
+		outStr = append(outStr, `\begin{center}`,
+			fmt.Sprintf(`\fbox{\parbox{0.9\linewidth}{\centering %s}}`, words.join(`\quad `)),
+			`\end{center}`)
+	}
+
+	questions, _ := c.Questions.Get(int(student))
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			text := numberBlanks(q.Question.string, `\textbf{(%d)}~\fillin{}`, qNum)
+			if c.QuestionPoints {
+				text += " " + pointsText(q.Points)
+			}
+			outStr = append(outStr, `\noindent`, text)
+			outStr = append(outStr, q.imageLatex()...)
+			outStr = append(outStr, `\par\vspace{0.25cm}`)
+		},
+	)
+	return outStr
+}
+
+func (e *EssaySt) TestLatex(student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(e.SectionTitle, e.Points, e.instructions())
+	outStr = append(outStr, e.Text)
+	questions, _ := e.Questions.Get(int(student))
+	return append(outStr, questionsLatex(questions, *e.GetHead(), false, qNum)...)
+}
+
+func (d *DiagramSt) TestLatex(student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(d.SectionTitle, d.Points, d.instructions())
+	if words := d.WordBank.get(int(student)); words.Size() != 0 {
+		outStr = append(outStr, `\begin{center}`,
+			fmt.Sprintf(`\fbox{\parbox{0.9\linewidth}{\centering %s}}`, words.join(`\quad `)),
+			`\end{center}`)
+	}
+
+	questions, _ := d.Questions.Get(int(student))
+	questions.Each(
+		func(_ int, q *QuestionsSt) {
+			first := qNum.CurrentNumber() + 1
+			qNum.AddNumber(uint32(len(q.Callouts)))
+			text := q.Question.string
+			if d.QuestionPoints {
+				text += " " + pointsText(q.Points)
+			}
+			outStr = append(outStr, `\noindent`, text)
+			outStr = append(outStr, q.diagramLatex(first)...)
+			outStr = append(outStr, `\noindent`, q.diagramBlanks(first), `\par\vspace{0.25cm}`)
+		},
+	)
+	return outStr
+}
+
 func (r *ReadingCompSt) TestLatex(student uint, qNum *QuestNumSt) []string {
-	outStr := testSectionBegin(r.SectionTitle, r.Points, r.Instructions)
+	outStr := testSectionBegin(r.SectionTitle, r.Points, r.instructions())
 	questions, _ := r.Questions.Get(int(student))
 	return append(outStr, []string{
 		fmt.Sprintf(`\qtitle{%s}`, r.Title),
@@ -25,8 +138,17 @@ func (r *ReadingCompSt) TestLatex(student uint, qNum *QuestNumSt) []string {
 	}...)
 }
 
+func (s *StimulusGroupsSt) TestLatex(student uint, qNum *QuestNumSt) []string {
+	outStr := testSectionBegin(s.SectionTitle, s.Points, s.instructions())
+	for _, group := range s.Groups[student] {
+		outStr = append(outStr, group.latex()...)
+		outStr = append(outStr, questionsLatex(group.Questions, *s.GetHead(), false, qNum)...)
+	}
+	return outStr
+}
+
 func (w *WordProblemSt) TestLatex(student uint, qNum *QuestNumSt) []string {
-	outStr := testSectionBegin(w.SectionTitle, w.Points, w.Instructions)
+	outStr := testSectionBegin(w.SectionTitle, w.Points, w.instructions())
 	outStr = append(outStr, w.Text)
 	questions, _ := w.Questions.Get(int(student))
 	return append(outStr, questionsLatex(questions, *w.GetHead(), false, qNum)...)
@@ -50,7 +172,7 @@ func (q *QuizSt) TestLatex(student uint, qNum *QuestNumSt) []string {
 }
 
 func (w *WordMatchSt) TestLatex(student uint, qNum *QuestNumSt) []string {
-	outStr := testSectionBegin(w.SectionTitle, w.Points, w.Instructions)
+	outStr := testSectionBegin(w.SectionTitle, w.Points, w.instructions())
 	outStr = append(outStr, w.Text)
 	words := w.getWords(student)
 	defines := w.getDefs(student)
@@ -69,7 +191,7 @@ func (w *WordMatchSt) TestLatex(student uint, qNum *QuestNumSt) []string {
 }
 
 func (p *PassageCompletionSt) TestLatex(student uint, qNum *QuestNumSt) []string {
-	outStr := testSectionBegin(p.SectionTitle, p.Points, p.Instructions)
+	outStr := testSectionBegin(p.SectionTitle, p.Points, p.instructions())
 	outStr = append(outStr, []string{
 		`\qformat{\hfill} \begin{questions}`,
 		`\titledquestion{} \fullwidth{`,
@@ -87,13 +209,13 @@ func (p *PassageCompletionSt) TestLatex(student uint, qNum *QuestNumSt) []string
 }
 
 func (c *CompQuestionsSt) TestLatex(student uint, qNum *QuestNumSt) []string {
-	outStr := testSectionBegin(c.SectionTitle, c.Points, c.Instructions)
+	outStr := testSectionBegin(c.SectionTitle, c.Points, c.instructions())
 	questions, _ := c.Questions.Get(int(student))
 	return append(outStr, questionsLatex(questions, *c.GetHead(), false, qNum)...)
 }
 
 func (c *CustomSt) TestLatex(student uint, qNum *QuestNumSt) []string {
-	outStr := testSectionBegin(c.SectionTitle, c.Points, c.Instructions)
+	outStr := testSectionBegin(c.SectionTitle, c.Points, c.instructions())
 	outStr = append(outStr, c.Text)
 	questions, _ := c.Questions.Get(0)
 	if questions.Size() != 0 {
@@ -20,7 +20,7 @@ type PathStrSt struct {
 
 type FlagsSt struct {
 	ShowAll, SaveTex, CreateDistro, CreateRtf, CreateForm, CreatePDF, DBImport,
-	ContinuousNumbering, ImportClass, ImportSession bool
+	ContinuousNumbering, ImportClass, ImportSession, MathImages, CreateMoodle, CreateQTI bool
 }
 
 type TestSt struct {
@@ -37,40 +37,80 @@ type QuestgenQuestionSt struct {
 }
 
 type QuestionsSt struct {
-	Answer   uint           `json:"answer"`
-	NumCol   uint           `json:"numCol"`
-	Used     uint           `json:"used"`
-	Required bool           `json:"required"`
-	Choices  WordsSt        `json:"choices"`
-	Answers  WordsSt        `json:"answers"`
-	Question NLStringSt     `json:"question"`
-	Parts    NLStringListSt `json:"parts"`
+	Answer       uint             `json:"answer"`
+	NumCol       uint             `json:"numCol"`
+	Used         uint             `json:"used"`
+	Required     bool             `json:"required"`
+	Points       uint             `json:"points"`
+	PartPoints   []uint           `json:"partPoints"`
+	Difficulty   string           `json:"difficulty"`
+	Tags         WordsSt          `json:"tags"`
+	Formula      string           `json:"formula"`
+	Distractors  WordsSt          `json:"distractors"`
+	Choices      WordsSt          `json:"choices"`
+	Correct      []uint           `json:"correct"`
+	Value        *float64         `json:"value"`
+	Tolerance    *ToleranceSt     `json:"tolerance"`
+	Units        string           `json:"units"`
+	Numeric      *NumericAnswerSt `json:"-"`
+	True         bool             `json:"true"`
+	Correction   NLStringSt       `json:"correction"`
+	KeepChoices  bool             `json:"-"`
+	Items        WordsSt          `json:"items"`
+	Rubric       *RubricSt        `json:"rubric"`
+	Callouts     []CalloutSt      `json:"callouts"`
+	Ordering     bool             `json:"-"`
+	Sequence     []int            `json:"-"`
+	Dropdown     bool             `json:"-"`
+	Image        *ImageSt         `json:"image"`
+	ChoiceImages []*ImageSt       `json:"choiceImages"`
+	Feedback     WordsSt          `json:"feedback"`
+	Answers      WordsSt          `json:"answers"`
+	Question     NLStringSt       `json:"question"`
+	Parts        NLStringListSt   `json:"parts"`
 }
 
 type JSONSectionSt struct {
-	Type             string        `json:"type"`
-	SectionTitle     string        `json:"sectionTitle"`
-	NumLines         string        `json:"numLines"`
-	Title            string        `json:"title"`
-	Points           uint          `json:"points"`
-	NumQuest         uint          `json:"questionsOntest"`
-	NumCol           uint          `json:"numCol"`
-	AnswerLines      bool          `json:"answerLines"`
-	QuizBox          bool          `json:"quizBox"`
-	KeepOrder        bool          `json:"keepOrder"`
-	AnswerText       WordsSt       `json:"answerText"`
-	WordList         WordsSt       `json:"word-list"`
-	Include          WordsSt       `json:"include"`
-	IncludeQuestgen  WordsSt       `json:"includeQuestgen"`
-	IncludeAiken     WordsSt       `json:"includeAiken"`
-	Answers          WordsSt       `json:"answers"`
-	ColumnHead       WordsSt       `json:"columnHead"`
-	Instructions     NLStringSt    `json:"instructions"`
-	FormInstructions NLStringSt    `json:"formInstructions"`
-	Text             NLStringSt    `json:"text"`
-	Words            WordDefMapSt  `json:"words"`
-	Questions        QuestionSetSt `json:"questions"`
-	AssetDir         string        `json:"-"`
+	Type             string         `json:"type"`
+	SectionTitle     string         `json:"sectionTitle"`
+	NumLines         string         `json:"numLines"`
+	Title            string         `json:"title"`
+	Points           uint           `json:"points"`
+	NegativeMarking  float64        `json:"negativeMarking"`
+	NumQuest         uint           `json:"questionsOntest"`
+	NumCol           uint           `json:"numCol"`
+	AnswerLines      bool           `json:"answerLines"`
+	QuizBox          bool           `json:"quizBox"`
+	KeepOrder        bool           `json:"keepOrder"`
+	CorrectFalse     bool           `json:"correctFalse"`
+	ExtraDefinitions uint           `json:"extraDefinitions"`
+	WordBank         bool           `json:"wordBank"`
+	Tolerance        ToleranceSt    `json:"tolerance"`
+	Units            string         `json:"units"`
+	AnswerText       WordsSt        `json:"answerText"`
+	WordList         WordsSt        `json:"word-list"`
+	Include          WordsSt        `json:"include"`
+	IncludeQuestgen  WordsSt        `json:"includeQuestgen"`
+	IncludeAiken     WordsSt        `json:"includeAiken"`
+	IncludeGift      WordsSt        `json:"includeGift"`
+	IncludeMoodle    WordsSt        `json:"includeMoodle"`
+	IncludeQTI       WordsSt        `json:"includeQTI"`
+	IncludeMarkdown  WordsSt        `json:"includeMarkdown"`
+	IncludeCsv       []CsvIncludeSt `json:"includeCsv"`
+	Answers          WordsSt        `json:"answers"`
+	Distractors      WordsSt        `json:"distractors"`
+	ColumnHead       WordsSt        `json:"columnHead"`
+	Instructions     NLStringSt     `json:"instructions"`
+	FormInstructions NLStringSt     `json:"formInstructions"`
+	Text             NLStringSt     `json:"text"`
+	Words            WordDefMapSt   `json:"words"`
+	Questions        QuestionSetSt  `json:"questions"`
+	Blueprint        BlueprintSt    `json:"blueprint"`
+	Stimuli          []*StimulusSt  `json:"stimuli"`
+	GroupsOnTest     uint           `json:"groupsOnTest"`
+	Rubric           *RubricSt      `json:"rubric"`
+	AssetDir         string         `json:"-"`
+	Seed             int64          `json:"-"`
 }
 
 type TestJSONSt struct {
@@ -84,6 +124,7 @@ type TestJSONSt struct {
 	Time         uint            `json:"time"`
 	NoKey        bool            `json:"noKey"`
 	MinQuestions uint            `json:"minQuestions"`
+	Seed         int64           `json:"seed"`
 	Students     WordsSt         `json:"students"`
 	Classes      ClassMapSt      `json:"classes"`
 	Sections     []JSONSectionSt `json:"sections"`
@@ -100,16 +141,20 @@ type SectionHeadSt struct {
 	Title            string
 	NumLines         string
 	Points           uint
+	QuestionPoints   bool
+	NegativeMarking  float64
 	NumCol           uint
 	NumQuest         uint
 	AnswerLines      bool
 	QuizBox          bool
 	Quiz             bool
 	KeepOrder        bool
+	CorrectFalse     bool
 	Instructions     string
 	FormInstructions string
 	Text             string
 	ColumnHead       WordsSt
+	Seed             int64
 }
 
 type SectionSt interface {
@@ -136,6 +181,44 @@ type MultipleChoiceSt struct {
 	AllQuestions QuestionSetSt
 }
 
+type TrueFalseSt struct {
+	SectionHeadSt
+	Questions    QuestionListSt
+	AllQuestions QuestionSetSt
+}
+
+type NumericSt struct {
+	SectionHeadSt
+	Questions    QuestionListSt
+	AllQuestions QuestionSetSt
+}
+
+type OrderingSt struct {
+	SectionHeadSt
+	Questions    QuestionListSt
+	AllQuestions QuestionSetSt
+}
+
+type ClozeSt struct {
+	SectionHeadSt
+	Questions    QuestionListSt
+	AllQuestions QuestionSetSt
+	WordBank     StringListSt
+}
+
+type EssaySt struct {
+	SectionHeadSt
+	Questions    QuestionListSt
+	AllQuestions QuestionSetSt
+}
+
+type DiagramSt struct {
+	SectionHeadSt
+	Questions    QuestionListSt
+	AllQuestions QuestionSetSt
+	WordBank     StringListSt
+}
+
 type WordProblemSt struct {
 	SectionHeadSt
 	Questions    QuestionListSt
@@ -154,6 +237,14 @@ type ReadingCompSt struct {
 	AllQuestions QuestionSetSt
 }
 
+type StimulusGroupsSt struct {
+	SectionHeadSt
+	Groups       [][]StimulusGroupSt
+	Questions    QuestionListSt
+	AllStimuli   []*StimulusSt
+	AllQuestions QuestionSetSt
+}
+
 type QuizSt struct {
 	SectionHeadSt
 	Questions    QuestionListSt
@@ -170,9 +261,10 @@ type CustomSt struct {
 
 type PassageCompletionSt struct {
 	SectionHeadSt
-	WordList  StringListSt
-	Answers   StringListSt
-	Questions QuestionListSt // not used
+	WordList     StringListSt
+	Answers      StringListSt
+	Questions    QuestionListSt
+	AllQuestions QuestionSetSt
 }
 
 type TestHeadSt struct {
@@ -185,6 +277,7 @@ type TestHeadSt struct {
 	Date            string
 	Points          uint
 	Time            uint
+	Seed            int64
 	Quiz            bool
 	NoKey           bool
 	Dsn             string
@@ -223,7 +316,7 @@ func (cm *ClassMapSt) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &classes); err != nil {
 		return err
 	}
-	cm.Map = &treemap.Map[string, WordsSt]{}
+	cm.Map = treemap.NewWithStringComparator[WordsSt]()
 	for _, class := range classes {
 		cm.Map.Put(class.Name, class.Students)
 	}
@@ -266,8 +359,11 @@ type WordDefSt struct {
 	Answer NLStringSt
 }
 
+// WordListSt is one copy's words, each with its definition and the letter
+// of that definition among the Options.
 type WordListSt struct {
 	*arraylist.List[*WordDefSt]
+	Options NLStringListSt
 }
 
 func (wm WordMatchSt) getWords(student uint) NLStringListSt {
@@ -328,7 +424,7 @@ func (wdm *WordDefMapSt) get(word NLStringSt) NLStringSt {
 
 func (wdm *WordDefMapSt) fixMissing() {
 	if wdm.Map == nil {
-		wdm.Map = &treemap.Map[NLStringSt, NLStringSt]{}
+		wdm.Map = newWordDefMap()
 	}
 }
 
@@ -374,10 +470,16 @@ type NLStringSt struct {
 	string
 }
 
+// UnmarshalJSON accepts either a list of lines or, as YAML and TOML block
+// strings produce, a single string whose final newline is dropped.
 func (nls *NLStringSt) UnmarshalJSON(data []byte) error {
 	wSet := make([]string, 0)
 	if err := json.Unmarshal(data, &wSet); err != nil {
-		return err
+		block := ""
+		if json.Unmarshal(data, &block) != nil {
+			return err
+		}
+		wSet = append(wSet, strings.TrimSuffix(block, "\n"))
 	}
 	nls.string = strings.ReplaceAll(strings.Join(wSet, "\n"), "\u200b", ``)
 
@@ -385,7 +487,11 @@ func (nls *NLStringSt) UnmarshalJSON(data []byte) error {
 }
 
 func (nls *NLStringSt) rtfString() string {
-	outStr := strings.ReplaceAll(nls.string, "\n", " ")
+	return rtfEscape(MathUnicode(nls.string))
+}
+
+func rtfEscape(text string) string {
+	outStr := strings.ReplaceAll(text, "\n", " ")
 	outStr = strings.ReplaceAll(outStr, "\u2019", `\'92`)
 	outStr = strings.ReplaceAll(outStr, "\u201c", `\'93`)
 	outStr = strings.ReplaceAll(outStr, "\u201d", `\'94`)
@@ -395,7 +501,7 @@ func (nls *NLStringSt) rtfString() string {
 }
 
 func (nls *NLStringSt) CleanString() string {
-	outStr := strings.ReplaceAll(nls.string, "\n", " ")
+	outStr := strings.ReplaceAll(MathUnicode(nls.string), "\n", " ")
 	outStr = strings.ReplaceAll(outStr, `\fillin\`, "________")
 	return outStr
 }
@@ -405,19 +511,16 @@ type NLStringListSt struct {
 }
 
 func (nls *NLStringListSt) UnmarshalJSON(data []byte) error {
-	wSet := make([][]string, 0)
+	wSet := make([]NLStringSt, 0)
 	if err := json.Unmarshal(data, &wSet); err != nil {
 		return err
 	}
-	nls.List = arraylist.New[NLStringSt]()
-	for _, strs := range wSet {
-		nls.Add(NLStringSt{strings.Join(strs, "\n")})
-	}
+	nls.List = arraylist.New(wSet...)
 	return nil
 }
 
-func (nls *NLStringListSt) getRandom() string {
-	s, _ := nls.Get(rand.Intn(nls.Size()))
+func (nls *NLStringListSt) getRandom(rnd *rand.Rand) string {
+	s, _ := nls.Get(rnd.Intn(nls.Size()))
 	return s.string
 }
 
@@ -449,15 +552,10 @@ func (nls *NLStringListSt) values() []string {
 }
 
 func (wm WordMatchSt) getDefs(student uint) NLStringListSt {
-	defs := NLStringListSt{List: arraylist.New[NLStringSt]()}
 	if wordList, found := wm.Get(int(student)); found {
-		wordList.Each(
-			func(_ int, w *WordDefSt) {
-				defs.Add(w.Def)
-			},
-		)
+		return wordList.Options
 	}
-	return defs
+	return NLStringListSt{List: arraylist.New[NLStringSt]()}
 }
 
 func (wm WordMatchSt) getAnswers(student uint) *arraylist.List[string] {
@@ -588,9 +686,9 @@ func ternary[T any](condition bool, ifOutput T, elseOutput T) T {
 	}
 }
 
-func shuffleSlice[T any](items []T) []T {
+func shuffleSlice[T any](rnd *rand.Rand, items []T) []T {
 	newItems := items
-	rand.Shuffle(
+	rnd.Shuffle(
 		len(newItems),
 		func(i, j int) {
 			newItems[i], newItems[j] = newItems[j], newItems[i]
@@ -0,0 +1,933 @@
+package testparts
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	aiken "github.com/aldinokemal/go-aiken"
+	"github.com/daichi-m/go18ds/lists/arraylist"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"muzzammil.xyz/jsonc"
+)
+
+// TestSchema is the JSON Schema for test specification files. The question
+// and word include files are described by its "questions" and "wordDefs"
+// definitions.
+//
+//go:embed testSchema.json
+var TestSchema []byte
+
+const (
+	testSchemaURL = "testSchema.json"
+
+	// word-match sections offer five choices per word in Google Forms and
+	// four in the live quiz, so fewer words than this cannot be distracted.
+	minWordMatchWords = 5
+
+	// templateCheckDraws is how many sets of numbers a parameterized question
+	// is tried with, to catch formulas such as a/(a-b) that fail for some.
+	templateCheckDraws = 20
+)
+
+// ValidationErrorSt is a single problem found in a test specification or
+// one of its include files. Line and Column are 1-based, zero when unknown.
+type ValidationErrorSt struct {
+	File    string
+	Line    uint
+	Column  uint
+	Path    string
+	Message string
+}
+
+func (ve ValidationErrorSt) Error() string {
+	location := ve.File
+	if ve.Line != 0 {
+		location = fmt.Sprintf("%s:%d:%d", ve.File, ve.Line, ve.Column)
+	}
+	if ve.Path == "" {
+		return fmt.Sprintf("%s: %s", location, ve.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, ve.Path, ve.Message)
+}
+
+// ValidationErrorsSt collects every problem found in one validation pass.
+type ValidationErrorsSt []ValidationErrorSt
+
+func (ves ValidationErrorsSt) Error() string {
+	return strings.Join(stringsUsing(ves, func(ve ValidationErrorSt) string {
+		return ve.Error()
+	}), "\n")
+}
+
+// ValidateTestJson checks a test file and everything it includes against
+// TestSchema and the semantic rules the generator relies on. The returned
+// error is a ValidationErrorsSt when the file could be read.
+func ValidateTestJson(fileName, assetdir string) error {
+	_, err := readTestJson(assetdir+"/"+fileName, assetdir)
+	return err
+}
+
+func readTestJson(filePath, assetdir string) (TestJSONSt, error) {
+	spec, err := loadSpecFile(filePath)
+	if err != nil {
+		return TestJSONSt{}, err
+	}
+
+	spec, errs := spec.resolve(assetdir, nil)
+	if spec == nil {
+		return TestJSONSt{}, errs
+	}
+	spec.applySectionDefaults()
+
+	errs = append(errs, spec.validateSchema("")...)
+
+	testJSON := TestJSONSt{}
+	if err := json.Unmarshal(spec.data, &testJSON); err != nil {
+		if len(errs) == 0 {
+			errs = append(errs, spec.errorAt("", "%v", err))
+		}
+		return TestJSONSt{}, errs
+	}
+
+	if errs = append(errs, spec.checkTest(testJSON, assetdir)...); len(errs) != 0 {
+		return TestJSONSt{}, errs
+	}
+
+	return testJSON, nil
+}
+
+// ---- Specification files ----
+
+type specPosSt struct {
+	file         string
+	line, column uint
+}
+
+type specFileSt struct {
+	path      string
+	data      []byte
+	positions map[string]specPosSt
+}
+
+// loadSpecFile reads a JSONC, YAML or TOML file, chosen by its extension.
+// Syntax errors are returned as ValidationErrorsSt.
+func loadSpecFile(filePath string) (*specFileSt, error) {
+	var spec *specFileSt
+	var err error
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		spec, err = loadYAMLSpec(filePath)
+	case ".toml":
+		spec, err = loadTOMLSpec(filePath)
+	default:
+		spec, err = loadJSONCSpec(filePath)
+	}
+
+	if spec != nil {
+		for pointer, pos := range spec.positions {
+			pos.file = filePath
+			spec.positions[pointer] = pos
+		}
+	}
+	return spec, err
+}
+
+func loadJSONCSpec(filePath string) (*specFileSt, error) {
+	original, data, err := jsonc.ReadFromFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &specFileSt{path: filePath, data: data}
+	spec.positions, err = indexPositions(original)
+	if syntaxErr, ok := err.(ValidationErrorSt); ok {
+		syntaxErr.File = filePath
+		return spec, ValidationErrorsSt{syntaxErr}
+	}
+
+	return spec, nil
+}
+
+// questionPool returns the question list held by an include file. A pool is
+// either a bare list or, as TOML requires, a table with a "questions" list.
+func (sf *specFileSt) questionPool() *specFileSt {
+	wrapped := map[string]json.RawMessage{}
+	if err := json.Unmarshal(sf.data, &wrapped); err != nil || wrapped["questions"] == nil {
+		return sf
+	}
+
+	pool := &specFileSt{
+		path:      sf.path,
+		data:      wrapped["questions"],
+		positions: map[string]specPosSt{},
+	}
+	for pointer, pos := range sf.positions {
+		if rest, found := strings.CutPrefix(pointer, "/questions"); found {
+			pool.positions[rest] = pos
+		}
+	}
+	return pool
+}
+
+func (sf *specFileSt) position(pointer string) specPosSt {
+	for {
+		if pos, found := sf.positions[pointer]; found || pointer == "" {
+			return pos
+		}
+		pointer = pointer[:strings.LastIndex(pointer, "/")]
+	}
+}
+
+func (sf *specFileSt) errorAt(pointer, format string, args ...any) ValidationErrorSt {
+	pos := sf.position(pointer)
+	return ValidationErrorSt{
+		File:    ternary(pos.file != "", pos.file, sf.path),
+		Line:    pos.line,
+		Column:  pos.column,
+		Path:    pointer,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func compileTestSchema(fragment string) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(testSchemaURL, bytes.NewReader(TestSchema)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(testSchemaURL + fragment)
+}
+
+// validateSchema checks the file against the schema definition named by
+// fragment, the whole test when fragment is empty.
+func (sf *specFileSt) validateSchema(fragment string) ValidationErrorsSt {
+	schema, err := compileTestSchema(fragment)
+	if err != nil {
+		return ValidationErrorsSt{sf.errorAt("", "unable to compile schema: %v", err)}
+	}
+
+	var doc any
+	if err := decodeDoc(sf.data, &doc); err != nil {
+		return ValidationErrorsSt{sf.errorAt("", "%v", err)}
+	}
+
+	validationErr := &jsonschema.ValidationError{}
+	if err := schema.Validate(doc); errors.As(err, &validationErr) {
+		return sf.schemaErrors(validationErr)
+	} else if err != nil {
+		return ValidationErrorsSt{sf.errorAt("", "%v", err)}
+	}
+	return nil
+}
+
+func (sf *specFileSt) schemaErrors(ve *jsonschema.ValidationError) ValidationErrorsSt {
+	leaves := schemaLeaves(ve)
+	errs := ValidationErrorsSt{}
+	for _, leaf := range leaves {
+		if !unevaluatedNoise(leaf, leaves) {
+			errs = append(errs, sf.errorAt(leaf.InstanceLocation, "%s", leaf.Message))
+		}
+	}
+	return errs
+}
+
+func schemaLeaves(ve *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(ve.Causes) == 0 {
+		return []*jsonschema.ValidationError{ve}
+	}
+
+	leaves := make([]*jsonschema.ValidationError, 0)
+	for _, cause := range ve.Causes {
+		leaves = append(leaves, schemaLeaves(cause)...)
+	}
+	return leaves
+}
+
+// unevaluatedNoise reports whether leaf only says a property is not allowed
+// because another error in the same object stopped the schema evaluating
+// it.
+func unevaluatedNoise(leaf *jsonschema.ValidationError, leaves []*jsonschema.ValidationError) bool {
+	if !strings.HasSuffix(leaf.KeywordLocation, "/unevaluatedProperties") {
+		return false
+	}
+	object := leaf.InstanceLocation[:strings.LastIndex(leaf.InstanceLocation, "/")+1]
+	for _, other := range leaves {
+		if !strings.HasSuffix(other.KeywordLocation, "/unevaluatedProperties") &&
+			strings.HasPrefix(other.InstanceLocation, object) {
+			return true
+		}
+	}
+	return false
+}
+
+// ---- Semantic checks ----
+
+func (sf *specFileSt) checkTest(testJSON TestJSONSt, assetdir string) ValidationErrorsSt {
+	errs := ValidationErrorsSt{}
+	for si, section := range testJSON.Sections {
+		errs = append(errs,
+			sf.checkSection(section, fmt.Sprintf("/sections/%d", si), assetdir)...)
+	}
+	return errs
+}
+
+func (sf *specFileSt) checkSection(section JSONSectionSt, pointer,
+	assetdir string) ValidationErrorsSt {
+	section.Include.fixMissing()
+	section.IncludeQuestgen.fixMissing()
+	section.IncludeAiken.fixMissing()
+	section.IncludeGift.fixMissing()
+	section.IncludeMoodle.fixMissing()
+	section.IncludeQTI.fixMissing()
+	section.IncludeMarkdown.fixMissing()
+	section.Questions.fixMissing()
+	section.Words.fixMissing()
+
+	if _, found := lookupSectionType(section.Type); !found {
+		return ValidationErrorsSt{sf.errorAt(pointer+"/type", "unknown section type %q", section.Type)}
+	}
+	if section.Type == "word-match" {
+		return sf.checkWordMatch(section, pointer, assetdir)
+	}
+	if section.Type == "stimulus-groups" {
+		return sf.checkStimuli(section, pointer, assetdir)
+	}
+
+	errs := sf.checkRubric(section.Rubric, pointer+"/rubric")
+	section.Questions.Each(
+		func(qi int, q *QuestionsSt) {
+			qPointer := fmt.Sprintf("%s/questions/%d", pointer, qi)
+			if rubric := ternary(q.Rubric != nil, q.Rubric, section.Rubric); rubric != nil &&
+				q.Points != 0 && q.Points != rubric.maxPoints() {
+				errs = append(errs, sf.errorAt(qPointer+"/points",
+					"the question is worth %d points but its rubric scores up to %d", q.Points, rubric.maxPoints()))
+			}
+			if section.Type == "true-false" && q.Choices.List != nil {
+				errs = append(errs, sf.errorAt(qPointer+"/choices",
+					"true-false statements have no choices, use \"true\" to mark the true ones"))
+			}
+			if section.Type == "ordering" && q.Items.fixMissing().Size() == 0 {
+				errs = append(errs, sf.errorAt(qPointer+"/items",
+					"ordering questions need the items to put in order"))
+			}
+			if section.Type == "cloze" {
+				errs = append(errs, sf.checkCloze(q, qPointer)...)
+			}
+			if section.Type == "diagram" {
+				if q.Image == nil || q.Image.File == "" {
+					errs = append(errs, sf.errorAt(qPointer+"/image",
+						"diagram questions need an image file to label"))
+				}
+				if len(q.Callouts) == 0 {
+					errs = append(errs, sf.errorAt(qPointer+"/callouts",
+						"diagram questions need callouts to label"))
+				}
+			}
+			if section.Type == "numeric" && q.Formula == "" {
+				if _, err := q.numericAnswer(section.Tolerance, section.Units); err != nil {
+					errs = append(errs, sf.errorAt(qPointer+"/value", "%v", err))
+				}
+			}
+			errs = append(errs, sf.checkQuestion(q, qPointer, assetdir)...)
+		},
+	)
+	pool := section.Questions.Values()
+
+	section.Include.Each(
+		func(ii int, inc string) {
+			incPointer := fmt.Sprintf("%s/include/%d", pointer, ii)
+			incSpec, incErrs := sf.loadInclude(assetdir, inc, incPointer, "#/$defs/questions")
+			if errs = append(errs, incErrs...); incSpec == nil {
+				return
+			}
+
+			incJSON := make([]*QuestionsSt, 0)
+			if err := json.Unmarshal(incSpec.data, &incJSON); err != nil {
+				if len(incErrs) == 0 {
+					errs = append(errs, incSpec.errorAt("", "%v", err))
+				}
+				return
+			}
+			for qi, q := range incJSON {
+				errs = append(errs, incSpec.checkQuestion(q, fmt.Sprintf("/%d", qi), assetdir)...)
+			}
+			pool = append(pool, incJSON...)
+		},
+	)
+
+	section.IncludeQuestgen.Each(
+		func(ii int, inc string) {
+			incPointer := fmt.Sprintf("%s/includeQuestgen/%d", pointer, ii)
+			incSpec, incErrs := sf.loadInclude(assetdir, inc, incPointer, "")
+			if errs = append(errs, incErrs...); incSpec == nil {
+				return
+			}
+
+			incQuestgenJSON := make([]*QuestgenQuestionSt, 0)
+			if err := json.Unmarshal(incSpec.data, &incQuestgenJSON); err != nil {
+				errs = append(errs, incSpec.errorAt("", "%v", err))
+				return
+			}
+			for range incQuestgenJSON {
+				pool = append(pool, &QuestionsSt{})
+			}
+		},
+	)
+
+	section.IncludeAiken.Each(
+		func(ii int, inc string) {
+			incPointer := fmt.Sprintf("%s/includeAiken/%d", pointer, ii)
+			if err := sf.checkExists(assetdir, inc, incPointer); err != nil {
+				errs = append(errs, *err)
+				return
+			}
+
+			incAiken, err := aiken.ReadAiken(assetdir + "/" + inc)
+			if err != nil {
+				errs = append(errs, ValidationErrorSt{File: assetdir + "/" + inc, Message: err.Error()})
+				return
+			}
+			for range incAiken {
+				pool = append(pool, &QuestionsSt{})
+			}
+		},
+	)
+
+	for _, bank := range section.bankIncludes() {
+		bank.files.Each(
+			func(ii int, inc string) {
+				items, err := sf.readBankInclude(assetdir, inc, fmt.Sprintf("%s/%s/%d", pointer, bank.key, ii), bank)
+				if err != nil {
+					errs = append(errs, *err)
+					return
+				}
+				questions, _, _ := bankPool(items, section.Type)
+				pool = append(pool, questions...)
+			},
+		)
+	}
+	for ii, inc := range section.IncludeCsv {
+		items, incErrs := sf.readCsvInclude(assetdir, inc, fmt.Sprintf("%s/includeCsv/%d", pointer, ii), section.Type)
+		errs = append(errs, incErrs...)
+		questions, _, _ := bankPool(items, section.Type)
+		pool = append(pool, questions...)
+	}
+
+	if section.NumQuest > uint(len(pool)) {
+		errs = append(errs, sf.errorAt(pointer+"/questionsOntest",
+			"questionsOntest is %d but the question pool only has %d questions",
+			section.NumQuest, len(pool)))
+	}
+
+	numQuest := ternary(section.NumQuest != 0, section.NumQuest, section.Blueprint.total())
+	if len(section.Blueprint) != 0 {
+		if err := section.Blueprint.check(pool, numQuest); err != nil {
+			errs = append(errs, sf.errorAt(pointer+"/blueprint", "%v", err))
+		}
+	}
+
+	for _, q := range pool {
+		if q.Rubric == nil {
+			q.Rubric = section.Rubric
+		}
+	}
+	if _, err := sectionPoints(pool, numQuest, section.Points); err != nil {
+		errs = append(errs, sf.errorAt(pointer+"/points", "%v", err))
+	} else if _, err := (QuestionSetSt{List: arraylist.New(pool...)}).poolPoints(numQuest, section.Points); err != nil {
+		errs = append(errs, sf.errorAt(pointer+"/points", "%v", err))
+	}
+
+	return errs
+}
+
+// checkStimuli makes sure a stimulus-groups section has stimuli to draw and
+// checks each one's image and questions.
+func (sf *specFileSt) checkStimuli(section JSONSectionSt, pointer,
+	assetdir string) ValidationErrorsSt {
+	errs := ValidationErrorsSt{}
+	if len(section.Stimuli) == 0 {
+		errs = append(errs, sf.errorAt(pointer, "stimulus-groups sections need stimuli"))
+	}
+	if section.GroupsOnTest > uint(len(section.Stimuli)) {
+		errs = append(errs, sf.errorAt(pointer+"/groupsOnTest",
+			"%d stimuli are drawn for each copy but there are only %d", section.GroupsOnTest, len(section.Stimuli)))
+	}
+	if _, err := section.stimulusPoints(); err != nil {
+		errs = append(errs, sf.errorAt(pointer+"/points", "%v", err))
+	}
+	for si, stimulus := range section.Stimuli {
+		sPointer := fmt.Sprintf("%s/stimuli/%d", pointer, si)
+		errs = append(errs, sf.checkImage(stimulus.Image, sPointer+"/image", assetdir)...)
+		stimulus.Questions.fixMissing()
+		stimulus.Questions.Each(
+			func(qi int, q *QuestionsSt) {
+				errs = append(errs, sf.checkQuestion(q, fmt.Sprintf("%s/questions/%d", sPointer, qi), assetdir)...)
+			},
+		)
+	}
+	return errs
+}
+
+func (sf *specFileSt) checkWordMatch(section JSONSectionSt, pointer,
+	assetdir string) ValidationErrorsSt {
+	errs := ValidationErrorsSt{}
+	words := map[string]string{}
+	section.Words.Each(
+		func(word, def NLStringSt) {
+			words[word.string] = def.string
+		},
+	)
+
+	section.Include.Each(
+		func(ii int, inc string) {
+			incPointer := fmt.Sprintf("%s/include/%d", pointer, ii)
+			incSpec, incErrs := sf.loadInclude(assetdir, inc, incPointer, "#/$defs/wordDefs")
+			if errs = append(errs, incErrs...); incSpec == nil {
+				return
+			}
+
+			incJSON := new(WordDefMapSt)
+			if err := incJSON.UnmarshalJSON(incSpec.data); err != nil {
+				if len(incErrs) == 0 {
+					errs = append(errs, incSpec.errorAt("", "%v", err))
+				}
+				return
+			}
+			incJSON.Each(
+				func(word, def NLStringSt) {
+					words[word.string] = def.string
+				},
+			)
+		},
+	)
+
+	for _, bank := range section.bankIncludes() {
+		bank.files.Each(
+			func(ii int, inc string) {
+				items, err := sf.readBankInclude(assetdir, inc, fmt.Sprintf("%s/%s/%d", pointer, bank.key, ii), bank)
+				if err != nil {
+					errs = append(errs, *err)
+					return
+				}
+				_, pairs, _ := bankPool(items, section.Type)
+				for _, pair := range pairs {
+					words[pair[0]] = pair[1]
+				}
+			},
+		)
+	}
+	for ii, inc := range section.IncludeCsv {
+		items, incErrs := sf.readCsvInclude(assetdir, inc, fmt.Sprintf("%s/includeCsv/%d", pointer, ii), section.Type)
+		errs = append(errs, incErrs...)
+		_, pairs, _ := bankPool(items, section.Type)
+		for _, pair := range pairs {
+			words[pair[0]] = pair[1]
+		}
+	}
+
+	defs := map[string]bool{}
+	for _, def := range words {
+		defs[def] = true
+	}
+	numQuest := ternary(section.NumQuest != 0, section.NumQuest, uint(len(words)))
+
+	switch {
+	case section.NumQuest > uint(len(words)):
+		errs = append(errs, sf.errorAt(pointer+"/questionsOntest",
+			"questionsOntest is %d but the section only has %d words",
+			section.NumQuest, len(words)))
+	case len(words) < minWordMatchWords:
+		errs = append(errs, sf.errorAt(pointer+"/words",
+			"word-match sections need at least %d words, found %d",
+			minWordMatchWords, len(words)))
+	case section.ExtraDefinitions == 0 && len(defs) == len(words) && numQuest < minWordMatchWords:
+		// Without extra or shared definitions each word's Forms choices
+		// are drawn from the words on its copy.
+		errs = append(errs, sf.errorAt(pointer+"/questionsOntest",
+			"questionsOntest is %d but Google Forms needs %d words on each copy to choose from",
+			numQuest, minWordMatchWords))
+	case section.NumQuest+section.ExtraDefinitions > uint(len(words)):
+		errs = append(errs, sf.errorAt(pointer+"/extraDefinitions",
+			"%d words on a copy and %d extra definitions need %d words but the section only has %d",
+			section.NumQuest, section.ExtraDefinitions, section.NumQuest+section.ExtraDefinitions, len(words)))
+	}
+
+	return errs
+}
+
+// readBankInclude reads the items of a question bank include file, or
+// returns what is wrong with it.
+func (sf *specFileSt) readBankInclude(assetdir, inc, pointer string, bank bankIncludeSt) ([]bankItemSt, *ValidationErrorSt) {
+	if err := sf.checkExists(assetdir, inc, pointer); err != nil {
+		return nil, err
+	}
+	items, err := bank.read(assetdir + "/" + inc)
+	if bankErr, isBank := err.(ValidationErrorSt); isBank {
+		return nil, &bankErr
+	}
+	if err != nil {
+		return nil, &ValidationErrorSt{File: assetdir + "/" + inc, Message: err.Error()}
+	}
+	return items, nil
+}
+
+// readCsvInclude reads the items of a spreadsheet include, with what is
+// wrong with its columns or any of its rows.
+func (sf *specFileSt) readCsvInclude(assetdir string, inc CsvIncludeSt, pointer,
+	sectionType string) ([]bankItemSt, ValidationErrorsSt) {
+	if err := sf.checkExists(assetdir, inc.File, pointer+"/file"); err != nil {
+		return nil, ValidationErrorsSt{*err}
+	}
+	items, rowErrs, err := inc.read(assetdir+"/"+inc.File, sectionType)
+	if err != nil {
+		return nil, ValidationErrorsSt{sf.errorAt(pointer, "%v", err)}
+	}
+	return items, rowErrs
+}
+
+func (sf *specFileSt) checkQuestion(q *QuestionsSt, pointer, assetdir string) ValidationErrorsSt {
+	errs := sf.checkImage(q.Image, pointer+"/image", assetdir)
+	for ci, img := range q.ChoiceImages {
+		errs = append(errs,
+			sf.checkImage(img, fmt.Sprintf("%s/choiceImages/%d", pointer, ci), assetdir)...)
+	}
+	if numChoices := q.Choices.fixMissing().Size(); len(q.ChoiceImages) > numChoices {
+		errs = append(errs, sf.errorAt(pointer+"/choiceImages",
+			"%d choice images for %d choices", len(q.ChoiceImages), numChoices))
+	}
+	if numChoices := q.Choices.Size(); numChoices != 0 && q.Feedback.fixMissing().Size() > numChoices {
+		errs = append(errs, sf.errorAt(pointer+"/feedback",
+			"%d choice feedbacks for %d choices", q.Feedback.Size(), numChoices))
+	}
+
+	errs = append(errs, sf.checkRubric(q.Rubric, pointer+"/rubric")...)
+
+	if numParts := q.Parts.fixMissing().Size(); len(q.PartPoints) != 0 && len(q.PartPoints) != numParts {
+		errs = append(errs, sf.errorAt(pointer+"/partPoints",
+			"%d part points for %d parts", len(q.PartPoints), numParts))
+	}
+	if total := sumPoints(q.PartPoints); q.Points != 0 && len(q.PartPoints) != 0 && total != q.Points {
+		errs = append(errs, sf.errorAt(pointer+"/points",
+			"the question is worth %d points but its parts add up to %d", q.Points, total))
+	}
+
+	if q.isParameterized() {
+		rnd := newRand(0)
+		for draw := 0; draw < templateCheckDraws; draw++ {
+			newQuest := &QuestionsSt{
+				Parts:   q.Parts.fixMissing(),
+				Choices: q.Choices.fixMissing(),
+				Answers: q.Answers.fixMissing(),
+			}
+			if err := newQuest.instantiate(q, rnd); err != nil {
+				field := ternary(q.Formula != "", "/formula", "/question")
+				errs = append(errs, sf.errorAt(pointer+field, "%v", err))
+				break
+			}
+		}
+	}
+
+	if q.Choices.Size() == 0 || q.Distractors.List != nil && q.Distractors.Size() != 0 {
+		return errs
+	}
+	if q.selectAll() {
+		for ci, choice := range q.Correct {
+			if choice < 1 || choice > uint(q.Choices.Size()) {
+				errs = append(errs, sf.errorAt(fmt.Sprintf("%s/correct/%d", pointer, ci),
+					"correct answer %d is not one of the %d choices", choice, q.Choices.Size()))
+			}
+		}
+		return errs
+	}
+	if q.Answer < 1 || q.Answer > uint(q.Choices.Size()) {
+		errs = append(errs, sf.errorAt(pointer+"/answer",
+			"answer %d is not one of the %d choices", q.Answer, q.Choices.Size()))
+	}
+	return errs
+}
+
+// checkRubric makes sure every criterion gives points, and any descriptors,
+// for each level.
+func (sf *specFileSt) checkRubric(rubric *RubricSt, pointer string) ValidationErrorsSt {
+	errs := ValidationErrorsSt{}
+	if rubric == nil {
+		return errs
+	}
+	for ci, criterion := range rubric.Criteria {
+		cPointer := fmt.Sprintf("%s/criteria/%d", pointer, ci)
+		if len(criterion.Points) != len(rubric.Levels) {
+			errs = append(errs, sf.errorAt(cPointer+"/points",
+				"%d points for %d levels", len(criterion.Points), len(rubric.Levels)))
+		}
+		if len(criterion.Descriptors) != 0 && len(criterion.Descriptors) != len(rubric.Levels) {
+			errs = append(errs, sf.errorAt(cPointer+"/descriptors",
+				"%d descriptors for %d levels", len(criterion.Descriptors), len(rubric.Levels)))
+		}
+	}
+	return errs
+}
+
+// checkCloze makes sure a cloze question has blanks and every blank has an
+// answer.
+func (sf *specFileSt) checkCloze(q *QuestionsSt, pointer string) ValidationErrorsSt {
+	blanks := templateRe.FindAllStringSubmatch(q.Question.string, -1)
+	if len(blanks) == 0 {
+		return ValidationErrorsSt{sf.errorAt(pointer+"/question",
+			"cloze questions need at least one {{answer}} blank")}
+	}
+	for bi, blank := range blanks {
+		if blank[1] == "" {
+			return ValidationErrorsSt{sf.errorAt(pointer+"/question", "blank %d has no answer", bi+1)}
+		}
+	}
+	return nil
+}
+
+func (sf *specFileSt) checkImage(img *ImageSt, pointer, assetdir string) ValidationErrorsSt {
+	if img == nil || img.File == "" {
+		return nil
+	}
+	if _, err := os.Stat(assetdir + "/" + img.File); err != nil {
+		return ValidationErrorsSt{sf.errorAt(pointer+"/file", "image %s does not exist", img.File)}
+	}
+	return nil
+}
+
+func (sf *specFileSt) checkExists(assetdir, inc, pointer string) *ValidationErrorSt {
+	if _, err := os.Stat(assetdir + "/" + inc); err != nil {
+		incErr := sf.errorAt(pointer, "include file %s does not exist", inc)
+		return &incErr
+	}
+	return nil
+}
+
+// loadInclude reads an include file and checks it against the schema
+// definition named by fragment, if any. The returned spec is nil when the
+// file cannot be read at all.
+func (sf *specFileSt) loadInclude(assetdir, inc, pointer,
+	fragment string) (*specFileSt, ValidationErrorsSt) {
+	if err := sf.checkExists(assetdir, inc, pointer); err != nil {
+		return nil, ValidationErrorsSt{*err}
+	}
+
+	incSpec, err := loadSpecFile(assetdir + "/" + inc)
+	if syntaxErrs, ok := err.(ValidationErrorsSt); ok {
+		return nil, syntaxErrs
+	} else if err != nil {
+		return nil, ValidationErrorsSt{sf.errorAt(pointer, "unable to load include file %s: %v", inc, err)}
+	}
+
+	switch fragment {
+	case "":
+		return incSpec, nil
+	case "#/$defs/questions":
+		incSpec = incSpec.questionPool()
+	}
+	return incSpec, incSpec.validateSchema(fragment)
+}
+
+// ---- Source positions ----
+
+// indexPositions maps the JSON pointer of every value in a JSONC document to
+// the line and column where it starts. Comments are skipped the same way
+// jsonc strips them, so positions refer to the file as written.
+func indexPositions(data []byte) (map[string]specPosSt, error) {
+	ps := &posScannerSt{
+		data:      data,
+		line:      1,
+		column:    1,
+		positions: map[string]specPosSt{},
+	}
+
+	if err := ps.value(""); err != nil {
+		return ps.positions, err
+	}
+	if ps.skip(); ps.off < len(ps.data) {
+		return ps.positions, ps.syntaxError("unexpected %q after the end of the document",
+			ps.data[ps.off])
+	}
+	return ps.positions, nil
+}
+
+type posScannerSt struct {
+	data         []byte
+	off          int
+	line, column uint
+	positions    map[string]specPosSt
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func (ps *posScannerSt) peek() byte {
+	if ps.off < len(ps.data) {
+		return ps.data[ps.off]
+	}
+	return 0
+}
+
+func (ps *posScannerSt) advance() {
+	switch {
+	case ps.data[ps.off] == '\n':
+		ps.line++
+		ps.column = 1
+	case ps.data[ps.off]&0xC0 != 0x80:
+		ps.column++
+	}
+	ps.off++
+}
+
+func (ps *posScannerSt) syntaxError(format string, args ...any) ValidationErrorSt {
+	return ValidationErrorSt{
+		Line:    ps.line,
+		Column:  ps.column,
+		Message: "syntax error, " + fmt.Sprintf(format, args...),
+	}
+}
+
+func (ps *posScannerSt) skipLine() {
+	for ps.off < len(ps.data) && ps.peek() != '\n' {
+		ps.advance()
+	}
+}
+
+func (ps *posScannerSt) skip() {
+	for ps.off < len(ps.data) {
+		switch {
+		case strings.IndexByte(" \t\r\n", ps.peek()) >= 0:
+			ps.advance()
+		case ps.peek() == '#',
+			bytes.HasPrefix(ps.data[ps.off:], []byte("//")):
+			ps.skipLine()
+		case bytes.HasPrefix(ps.data[ps.off:], []byte("/*")):
+			for ps.off < len(ps.data) && !bytes.HasPrefix(ps.data[ps.off:], []byte("*/")) {
+				ps.advance()
+			}
+			if ps.off < len(ps.data) {
+				ps.advance()
+				ps.advance()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (ps *posScannerSt) value(pointer string) error {
+	if ps.skip(); ps.off >= len(ps.data) {
+		return ps.syntaxError("unexpected end of file")
+	}
+
+	ps.positions[pointer] = specPosSt{line: ps.line, column: ps.column}
+	switch ps.peek() {
+	case '{':
+		return ps.object(pointer)
+	case '[':
+		return ps.array(pointer)
+	case '"':
+		_, err := ps.str()
+		return err
+	default:
+		return ps.literal()
+	}
+}
+
+func (ps *posScannerSt) object(pointer string) error {
+	ps.advance()
+	if ps.skip(); ps.peek() == '}' {
+		ps.advance()
+		return nil
+	}
+
+	for {
+		if ps.skip(); ps.peek() != '"' {
+			return ps.syntaxError("expected a property name")
+		}
+		key, err := ps.str()
+		if err != nil {
+			return err
+		}
+		if ps.skip(); ps.peek() != ':' {
+			return ps.syntaxError("expected ':' after property %q", key)
+		}
+		ps.advance()
+		if err := ps.value(pointer + "/" + pointerEscaper.Replace(key)); err != nil {
+			return err
+		}
+
+		switch ps.skip(); ps.peek() {
+		case ',':
+			ps.advance()
+		case '}':
+			ps.advance()
+			return nil
+		default:
+			return ps.syntaxError("expected ',' or '}' after property %q", key)
+		}
+	}
+}
+
+func (ps *posScannerSt) array(pointer string) error {
+	ps.advance()
+	if ps.skip(); ps.peek() == ']' {
+		ps.advance()
+		return nil
+	}
+
+	for index := 0; ; index++ {
+		if err := ps.value(fmt.Sprintf("%s/%d", pointer, index)); err != nil {
+			return err
+		}
+
+		switch ps.skip(); ps.peek() {
+		case ',':
+			ps.advance()
+		case ']':
+			ps.advance()
+			return nil
+		default:
+			return ps.syntaxError("expected ',' or ']' after array element")
+		}
+	}
+}
+
+func (ps *posScannerSt) str() (string, error) {
+	start := ps.off
+	ps.advance()
+	for ps.peek() != '"' {
+		if ps.peek() == '\\' {
+			ps.advance()
+		}
+		if ps.peek() == 0 || ps.peek() == '\n' {
+			return "", ps.syntaxError("unterminated string")
+		}
+		ps.advance()
+	}
+	ps.advance()
+
+	value := ""
+	if err := json.Unmarshal(ps.data[start:ps.off], &value); err != nil {
+		return "", ps.syntaxError("invalid string, %v", err)
+	}
+	return value, nil
+}
+
+func (ps *posScannerSt) literal() error {
+	start := ps.off
+	for ps.off < len(ps.data) && strings.IndexByte(",:]} \t\r\n/#", ps.peek()) < 0 {
+		ps.advance()
+	}
+
+	token := ps.data[start:ps.off]
+	if len(token) == 0 {
+		return ps.syntaxError("unexpected %q", ps.peek())
+	}
+	if !json.Valid(token) {
+		return ps.syntaxError("invalid value %q", token)
+	}
+	return nil
+}